@@ -1,21 +1,56 @@
 package accounts
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"sort"
 
-	"github.com/libonomy/ed25519"
+	"github.com/libonomy/wallet-cli/os/crypto"
 	"github.com/libonomy/wallet-cli/wallet/address"
 )
 
 type Account struct {
 	Name    string
-	PrivKey ed25519.PrivateKey // the pub & private key
-	PubKey  ed25519.PublicKey  // only the pub key part
+	Scheme  KeyScheme
+	PrivKey *crypto.Secret // nil while the account is locked
+	PubKey  []byte
 }
 
 func (a *Account) Address() address.Address {
-	return address.BytesToAddress(a.PubKey[:])
+	return a.Scheme.Address(a.PubKey)
+}
+
+// IsLocked returns true iff the account private key is not available.
+func (a *Account) IsLocked() bool {
+	return a.PrivKey.IsWiped()
+}
+
+// Lock wipes the account private key from memory.
+func (a *Account) Lock() {
+	a.PrivKey.Wipe()
+	a.PrivKey = nil
+}
+
+// Sign signs msg with the account private key using the account key scheme.
+func (a *Account) Sign(msg []byte) ([]byte, error) {
+	if a.IsLocked() {
+		return nil, fmt.Errorf("account `%s` is locked", a.Name)
+	}
+	return a.Scheme.Sign(a.PrivKey.Bytes(), msg)
+}
+
+// Decrypt decrypts a message encrypted to the account public key.
+func (a *Account) Decrypt(ciphertext []byte) ([]byte, error) {
+	if a.IsLocked() {
+		return nil, fmt.Errorf("account `%s` is locked", a.Name)
+	}
+	return a.Scheme.Decrypt(a.PrivKey.Bytes(), ciphertext)
+}
+
+// Verify returns true iff sig is a valid signature of msg by the account.
+func (a *Account) Verify(msg, sig []byte) bool {
+	return a.Scheme.Verify(a.PubKey, msg, sig)
 }
 
 func StringAddress(addr address.Address) string {
@@ -27,26 +62,195 @@ type AccountInfo struct {
 	Balance string
 }
 
+// KeyScheme returns the key scheme the account keys were generated with.
+func (k AccountKeys) KeyScheme() (KeyScheme, error) {
+	return GetScheme(k.Scheme)
+}
+
+// Address returns the address derived from the stored public key.
+func (k AccountKeys) Address() (address.Address, error) {
+	scheme, err := k.KeyScheme()
+	if err != nil {
+		return address.Address{}, err
+	}
+	pub, err := scheme.ParsePublicKey(k.PubKey)
+	if err != nil {
+		return address.Address{}, err
+	}
+	return scheme.Address(pub), nil
+}
+
+// GetAccount returns account name. The returned account is locked, see UnlockAccount.
 func (s Store) GetAccount(name string) (*Account, error) {
 	if acc, ok := s[name]; ok {
-		priv, err := hex.DecodeString(acc.PrivKey)
+		scheme, err := acc.KeyScheme()
 		if err != nil {
 			return nil, err
 		}
-		pub, err := hex.DecodeString(acc.PubKey)
+		pub, err := scheme.ParsePublicKey(acc.PubKey)
 		if err != nil {
 			return nil, err
 		}
 
-		return &Account{name, priv, pub}, nil
+		return &Account{name, scheme, nil, pub}, nil
 	}
 	return nil, fmt.Errorf("account not found")
 }
 
+// IsEncrypted returns true iff the private key of account name is stored encrypted.
+// Accounts created before keys were encrypted are encrypted when they are first unlocked.
+func (s Store) IsEncrypted(name string) bool {
+	acc, ok := s[name]
+	return ok && acc.Crypto != nil
+}
+
+// UnlockAccount decrypts the private key of acc using passphrase. Unencrypted private keys are loaded as they are,
+// see EncryptAccount.
+func (s Store) UnlockAccount(acc *Account, passphrase []byte) error {
+	keys, ok := s[acc.Name]
+	if !ok {
+		return fmt.Errorf("account not found")
+	}
+	if keys.HSM != nil {
+		return fmt.Errorf("account `%s` is kept in %s, its private key cannot be unlocked", acc.Name, keys.HSM)
+	}
+
+	var priv []byte
+	var err error
+	if keys.Crypto != nil {
+		priv, err = decryptKey(&keys, passphrase)
+	} else {
+		priv, err = acc.Scheme.ParsePrivateKey(keys.PrivKey)
+	}
+	if err != nil {
+		return err
+	}
+	key := crypto.NewSecret(priv)
+
+	pub, err := acc.Scheme.PublicKey(key.Bytes())
+	if err != nil || !bytes.Equal(pub, acc.PubKey) {
+		key.Wipe()
+		return fmt.Errorf("private key does not match account `%s` public key", acc.Name)
+	}
+
+	acc.Lock()
+	acc.PrivKey = key
+	return nil
+}
+
+// CheckNetwork returns an error if the private key of account name is encrypted for another network than networkID.
+// Keys stored unencrypted or with the legacy cipher are not bound to a network.
+func (s Store) CheckNetwork(name string, networkID int8) error {
+	keys, ok := s[name]
+	if !ok {
+		return fmt.Errorf("account not found")
+	}
+	if keys.Crypto == nil || keys.Crypto.Cipher == cipherAES128CTR || keys.Crypto.NetworkID == networkID {
+		return nil
+	}
+	return fmt.Errorf("the key of account `%s` is bound to network %d but the wallet uses network %d",
+		name, keys.Crypto.NetworkID, networkID)
+}
+
+// EncryptAccount encrypts the private key of the unlocked account acc with a key derived from passphrase with
+// kdParams, bound to network networkID, and removes any unencrypted private key from the store. Callers should
+// persist the store afterwards.
+func (s Store) EncryptAccount(acc *Account, networkID int8, passphrase []byte, kdParams crypto.KDParams) error {
+	keys, ok := s[acc.Name]
+	if !ok {
+		return fmt.Errorf("account not found")
+	}
+	if acc.IsLocked() {
+		return fmt.Errorf("account `%s` is locked", acc.Name)
+	}
+
+	if err := encryptKey(&keys, acc.PrivKey.Bytes(), passphrase, networkID, kdParams); err != nil {
+		return err
+	}
+	keys.PrivKey = ""
+	s[acc.Name] = keys
+	return nil
+}
+
+// ChangePassphrase re-encrypts the private key of account name, currently encrypted with passphrase, with
+// newPassphrase and kdParams, along with its VRF private key if any. The key stays bound to its network.
+func (s Store) ChangePassphrase(name string, passphrase, newPassphrase []byte, kdParams crypto.KDParams) error {
+	acc, err := s.GetAccount(name)
+	if err != nil {
+		return err
+	}
+	if !s.IsEncrypted(name) {
+		return fmt.Errorf("account `%s` is stored unencrypted, unlock it to encrypt it", name)
+	}
+	if err := s.UnlockAccount(acc, passphrase); err != nil {
+		return err
+	}
+	defer acc.Lock()
+
+	var vrf *VRFKey
+	if old := s[name].VRF; old != nil {
+		priv, err := s.UnlockVRFKey(name, passphrase)
+		if err != nil {
+			return err
+		}
+		c, err := encryptVRFKey(s[name], old.PubKey, priv.Bytes(), newPassphrase, kdParams)
+		priv.Wipe()
+		if err != nil {
+			return err
+		}
+		vrf = &VRFKey{PubKey: old.PubKey, Crypto: c}
+	}
+
+	if err := s.EncryptAccount(acc, s[name].Crypto.NetworkID, newPassphrase, kdParams); err != nil {
+		return err
+	}
+	if vrf != nil {
+		keys := s[name]
+		keys.VRF = vrf
+		s[name] = keys
+	}
+	return nil
+}
+
+// AccountAddress returns the address of account name.
+func (s Store) AccountAddress(name string) (address.Address, error) {
+	acc, ok := s[name]
+	if !ok {
+		return address.Address{}, fmt.Errorf("account not found")
+	}
+	return acc.Address()
+}
+
+// ListAccounts returns the sorted aliases of all accounts which are not archived.
 func (s Store) ListAccounts() []string {
 	lst := make([]string, 0, len(s))
-	for key := range s {
-		lst = append(lst, key)
+	for key, acc := range s {
+		if !acc.Archived {
+			lst = append(lst, key)
+		}
+	}
+	sort.Strings(lst)
+	return lst
+}
+
+// ListArchivedAccounts returns the sorted aliases of all archived accounts.
+func (s Store) ListArchivedAccounts() []string {
+	lst := make([]string, 0)
+	for key, acc := range s {
+		if acc.Archived {
+			lst = append(lst, key)
+		}
 	}
+	sort.Strings(lst)
 	return lst
 }
+
+// AccountByAddress returns the alias of the local account holding addr, if any.
+func (s Store) AccountByAddress(addr address.Address) (string, bool) {
+	for name, acc := range s {
+		if a, err := acc.Address(); err == nil && a == addr {
+			return name, true
+		}
+	}
+	return "", false
+}
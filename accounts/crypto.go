@@ -0,0 +1,179 @@
+package accounts
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// CryptoData is passphrase encrypted data along with the params needed to decrypt it.
+type CryptoData struct {
+	Cipher     string          `json:"cipher"`
+	CipherText string          `json:"cipherText"`
+	CipherIv   string          `json:"cipherIv"`
+	Mac        string          `json:"mac,omitempty"`       // AES-128-CTR only, AEAD ciphers authenticate CipherText
+	NetworkID  int8            `json:"networkId,omitempty"` // network an encrypted account key is bound to
+	KDParams   crypto.KDParams `json:"kd"`
+}
+
+// Cipher suites of CryptoData.
+const (
+	CipherAES256GCM         = "AES-256-GCM"
+	CipherXChaCha20Poly1305 = "XChaCha20-Poly1305"
+	cipherAES128CTR         = "AES-128-CTR" // legacy, decryption only
+)
+
+var errWrongPassphrase = errors.New("wrong passphrase or corrupted data")
+
+// DefaultCipher is the cipher suite used to encrypt new data.
+var DefaultCipher = CipherAES256GCM
+
+// Encrypt encrypts data with a key derived from passphrase with kdParams using DefaultCipher. ad is authenticated
+// along with the data and must be given again to decrypt it.
+func Encrypt(data, passphrase, ad []byte, kdParams crypto.KDParams) (*CryptoData, error) {
+	return EncryptWith(DefaultCipher, data, passphrase, ad, kdParams)
+}
+
+// EncryptWith encrypts data with a key derived from passphrase with kdParams using the AEAD cipher suite named
+// cipherName.
+func EncryptWith(cipherName string, data, passphrase, ad []byte, kdParams crypto.KDParams) (*CryptoData, error) {
+	salt, err := crypto.GetRandomBytes(kdParams.SaltLen)
+	if err != nil {
+		return nil, errors.New("failed to generate random salt")
+	}
+	kdParams.Salt = hex.EncodeToString(salt)
+
+	dk, err := crypto.DeriveKey(passphrase, kdParams)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(dk)
+
+	aead, err := newAEAD(cipherName, dk)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := crypto.GetRandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	return &CryptoData{
+		Cipher:     cipherName,
+		CipherText: hex.EncodeToString(aead.Seal(nil, nonce, data, ad)),
+		CipherIv:   hex.EncodeToString(nonce),
+		KDParams:   kdParams,
+	}, nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt using passphrase and the associated data given to Encrypt. Legacy
+// AES-128-CTR data carries no associated data and ignores ad.
+func Decrypt(c *CryptoData, passphrase, ad []byte) ([]byte, error) {
+	cipherText, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(c.CipherIv)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Cipher == cipherAES128CTR {
+		return decryptCTR(c, cipherText, nonce, passphrase)
+	}
+
+	dk, err := crypto.DeriveKey(passphrase, c.KDParams)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(dk)
+
+	aead, err := newAEAD(c.Cipher, dk)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid cipher iv length")
+	}
+
+	data, err := aead.Open(nil, nonce, cipherText, ad)
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	return data, nil
+}
+
+// newAEAD returns the AEAD cipher suite named cipherName keyed by the first 32 bytes of dk.
+func newAEAD(cipherName string, dk []byte) (cipher.AEAD, error) {
+	if len(dk) < 32 {
+		return nil, errors.New("derived key too short")
+	}
+
+	switch cipherName {
+	case CipherAES256GCM:
+		block, err := aes.NewCipher(dk[:32])
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(dk[:32])
+	default:
+		return nil, errors.New("unsupported cipher " + cipherName)
+	}
+}
+
+// decryptCTR decrypts legacy AES-128-CTR data authenticated by SHA3-256(dk[16:32] || cipherText).
+func decryptCTR(c *CryptoData, cipherText, nonce, passphrase []byte) ([]byte, error) {
+	mac, err := hex.DecodeString(c.Mac)
+	if err != nil {
+		return nil, err
+	}
+
+	dk, err := crypto.DeriveKey(passphrase, c.KDParams)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(dk)
+	if len(dk) < 32 {
+		return nil, errors.New("derived key too short")
+	}
+
+	if subtle.ConstantTimeCompare(mac, crypto.Sha256(dk[16:32], cipherText)) != 1 {
+		return nil, errWrongPassphrase
+	}
+
+	return crypto.AesCTRXOR(dk[:16], cipherText, nonce)
+}
+
+// keyAD returns the associated data binding an encrypted private key to its scheme, public key and network.
+func keyAD(scheme, pubKey string, networkID int8) []byte {
+	if scheme == "" {
+		scheme = DefaultScheme
+	}
+	return []byte(fmt.Sprintf("libonomy-account-key:%s:%s:%d", scheme, strings.ToLower(pubKey), networkID))
+}
+
+// encryptKey encrypts the private key priv of keys with passphrase, bound to networkID.
+func encryptKey(keys *AccountKeys, priv, passphrase []byte, networkID int8, kdParams crypto.KDParams) error {
+	c, err := Encrypt(priv, passphrase, keyAD(keys.Scheme, keys.PubKey, networkID), kdParams)
+	if err != nil {
+		return err
+	}
+	c.NetworkID = networkID
+	keys.Crypto = c
+	return nil
+}
+
+// decryptKey decrypts the private key of keys with passphrase.
+func decryptKey(keys *AccountKeys, passphrase []byte) ([]byte, error) {
+	return Decrypt(keys.Crypto, passphrase, keyAD(keys.Scheme, keys.PubKey, keys.Crypto.NetworkID))
+}
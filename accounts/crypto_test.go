@@ -0,0 +1,119 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	data := []byte("libonomy private key")
+	ad := []byte("associated data")
+
+	for _, cipherName := range []string{CipherAES256GCM, CipherXChaCha20Poly1305} {
+		c, err := EncryptWith(cipherName, data, testPassphrase, ad, crypto.DefaultCypherParams)
+		assert.NoError(t, err)
+		assert.Equal(t, cipherName, c.Cipher)
+		assert.Empty(t, c.Mac)
+
+		decrypted, err := Decrypt(c, testPassphrase, ad)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decrypted)
+
+		_, err = Decrypt(c, []byte("poodles"), ad)
+		assert.Error(t, err, "wrong passphrase")
+		_, err = Decrypt(c, testPassphrase, []byte("other data"))
+		assert.Error(t, err, "wrong associated data")
+	}
+
+	_, err := EncryptWith("ROT13", data, testPassphrase, ad, crypto.DefaultCypherParams)
+	assert.Error(t, err)
+	_, err = EncryptWith(cipherAES128CTR, data, testPassphrase, ad, crypto.DefaultCypherParams)
+	assert.Error(t, err, "legacy cipher should not encrypt new data")
+
+	c, err := Encrypt(data, testPassphrase, ad, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCipher, c.Cipher)
+}
+
+func TestDecryptLegacyCTR(t *testing.T) {
+	data := []byte("libonomy private key")
+
+	// encrypt the way accounts were encrypted before AEAD suites
+	kdParams := crypto.DefaultCypherParams
+	salt, err := crypto.GetRandomBytes(kdParams.SaltLen)
+	assert.NoError(t, err)
+	kdParams.Salt = hex.EncodeToString(salt)
+	dk, err := crypto.DeriveKey(testPassphrase, kdParams)
+	assert.NoError(t, err)
+	nonce, err := crypto.GetRandomBytes(16)
+	assert.NoError(t, err)
+	cipherText, err := crypto.AesCTRXOR(dk[:16], data, nonce)
+	assert.NoError(t, err)
+
+	c := &CryptoData{
+		Cipher:     cipherAES128CTR,
+		CipherText: hex.EncodeToString(cipherText),
+		CipherIv:   hex.EncodeToString(nonce),
+		Mac:        hex.EncodeToString(crypto.Sha256(dk[16:32], cipherText)),
+		KDParams:   kdParams,
+	}
+
+	decrypted, err := Decrypt(c, testPassphrase, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+	_, err = Decrypt(c, []byte("poodles"), nil)
+	assert.Error(t, err)
+}
+
+func TestKeyBoundToMetadata(t *testing.T) {
+	s := Store{}
+	_, err := s.CreateAccount("alice", "", 7, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(7), s["alice"].Crypto.NetworkID)
+
+	// moving the key to another network or public key breaks decryption
+	keys := s["alice"]
+	c := *keys.Crypto
+	c.NetworkID = 1
+	keys.Crypto = &c
+	s["alice"] = keys
+	acc, err := s.GetAccount("alice")
+	assert.NoError(t, err)
+	assert.Error(t, s.UnlockAccount(acc, testPassphrase))
+
+	c.NetworkID = 7
+	assert.NoError(t, s.UnlockAccount(acc, testPassphrase))
+
+	acc.Lock()
+
+	_, err = s.CreateAccount("bob", "", 7, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	keys.PubKey = s["bob"].PubKey
+	s["alice"] = keys
+	acc, err = s.GetAccount("alice")
+	assert.NoError(t, err)
+	assert.Error(t, s.UnlockAccount(acc, testPassphrase))
+}
+
+func TestCheckNetwork(t *testing.T) {
+	s := Store{}
+	_, err := s.CreateAccount("alice", "", 7, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.CheckNetwork("alice", 7))
+	assert.Error(t, s.CheckNetwork("alice", 1))
+	assert.Error(t, s.CheckNetwork("bob", 7))
+}
+
+func TestDecryptLegacyCTRShortKey(t *testing.T) {
+	kdParams := crypto.DefaultCypherParams
+	kdParams.Salt = hex.EncodeToString(make([]byte, kdParams.SaltLen))
+	kdParams.DKLen = 16
+
+	c := &CryptoData{Cipher: cipherAES128CTR, CipherText: "00", CipherIv: hex.EncodeToString(make([]byte, 16)), KDParams: kdParams}
+	_, err := Decrypt(c, testPassphrase, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// HSMScheme is the key scheme of accounts kept in PKCS#11 tokens.
+const HSMScheme = "ed25519-rfc8032"
+
+// HSMKey references an ed25519 key pair kept in a PKCS#11 token. The private key never leaves the token.
+type HSMKey struct {
+	Module string `json:"module"` // path of the PKCS#11 library
+	Token  string `json:"token"`  // token label
+	KeyID  string `json:"keyId"`  // hex encoded CKA_ID of the key objects
+}
+
+func (k HSMKey) String() string {
+	return fmt.Sprintf("token `%s` key %s", k.Token, k.KeyID)
+}
+
+// AddHSMAccount stores the token key with public key pub as alias. The returned account stays locked, its
+// messages are signed by the token.
+func (s Store) AddHSMAccount(alias string, key HSMKey, pub []byte) (*Account, error) {
+	if _, ok := s[alias]; ok {
+		return nil, fmt.Errorf("account `%s` already exists", alias)
+	}
+	if _, err := hex.DecodeString(key.KeyID); err != nil || key.KeyID == "" {
+		return nil, fmt.Errorf("invalid key ID `%s`", key.KeyID)
+	}
+	scheme, err := GetScheme(HSMScheme)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := scheme.ParsePublicKey(hex.EncodeToString(pub)); err != nil {
+		return nil, err
+	}
+
+	s[alias] = AccountKeys{Scheme: HSMScheme, PubKey: scheme.EncodeKey(pub), HSM: &key}
+	return &Account{Name: alias, Scheme: scheme, PubKey: pub}, nil
+}
+
+// HSMKey returns the token key of account name, if it is kept in a PKCS#11 token.
+func (s Store) HSMKey(name string) (*HSMKey, bool) {
+	acc, ok := s[name]
+	if !ok || acc.HSM == nil {
+		return nil, false
+	}
+	key := *acc.HSM
+	return &key, true
+}
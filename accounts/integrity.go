@@ -0,0 +1,152 @@
+package accounts
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/crypto/sha3"
+)
+
+// ErrTampered is returned by LoadAccounts along with the loaded accounts when the wallet file integrity tag does
+// not match its contents. Either the passphrase is wrong or the file was modified outside of the wallet.
+var ErrTampered = errors.New("wallet integrity check failed: wrong wallet passphrase or the wallet file was modified")
+
+// ErrUnprotected is returned by StoreAccounts for wallets without a wallet passphrase. Every stored wallet is
+// protected, so a wallet file without an integrity tag cannot be told apart from a file whose tag was removed.
+var ErrUnprotected = errors.New("the wallet has no wallet passphrase")
+
+// Integrity authenticates the whole wallet file contents with a key derived from the wallet passphrase.
+type Integrity struct {
+	KDParams crypto.KDParams `json:"kd"`
+	Mac      string          `json:"mac"`
+}
+
+// IsProtected returns true iff the wallet contents are authenticated with a wallet passphrase.
+func (m *Metadata) IsProtected() bool {
+	return m.Integrity != nil
+}
+
+// IsLegacy returns true iff the wallet was upgraded from a file format without integrity tags and has no wallet
+// passphrase yet. A protected wallet file rewritten in such a format also loads as legacy, callers that know the
+// wallet was protected use MarkTampered.
+func (m *Metadata) IsLegacy() bool {
+	return m.legacy && !m.IsProtected() && !m.tampered
+}
+
+// MarkTampered makes the wallet fail integrity verification, for callers that know a wallet loaded without an
+// integrity tag was protected before. The wallet is then never written back.
+func (m *Metadata) MarkTampered() {
+	m.tampered = true
+}
+
+// IsTampered returns true iff the wallet file failed integrity verification when it was loaded.
+// Tampered wallets are never written back, so the tag keeps failing until the file is fixed.
+func (m *Metadata) IsTampered() bool {
+	return m.tampered
+}
+
+// SetPassphrase protects the wallet contents with a key derived from passphrase with kdParams. The key replaces any
+// previous one and is used to authenticate the wallet whenever it is stored.
+func (m *Metadata) SetPassphrase(passphrase []byte, kdParams crypto.KDParams) error {
+	if m.tampered {
+		return ErrTampered
+	}
+
+	salt, err := crypto.GetRandomBytes(kdParams.SaltLen)
+	if err != nil {
+		return errors.New("failed to generate random salt")
+	}
+	kdParams.Salt = hex.EncodeToString(salt)
+
+	key, err := crypto.DeriveKey(passphrase, kdParams)
+	if err != nil {
+		return err
+	}
+
+	m.key.Wipe()
+	m.key = crypto.NewSecret(key)
+	m.Integrity = &Integrity{KDParams: kdParams}
+	return nil
+}
+
+// CheckPassphrase returns true iff passphrase is the passphrase the wallet contents are authenticated with.
+func (m *Metadata) CheckPassphrase(passphrase []byte) bool {
+	if !m.IsProtected() || m.key.IsWiped() {
+		return false
+	}
+
+	key, err := crypto.DeriveKey(passphrase, m.Integrity.KDParams)
+	if err != nil {
+		return false
+	}
+	defer crypto.Wipe(key)
+
+	return hmac.Equal(key, m.key.Bytes())
+}
+
+// sign sets the integrity tag of the wallet contents.
+func (m *Metadata) sign(store Store) error {
+	if m.tampered {
+		return ErrTampered
+	}
+	if !m.IsProtected() {
+		return ErrUnprotected
+	}
+
+	mac, err := m.mac(m.key.Bytes(), store)
+	if err != nil {
+		return err
+	}
+	m.Integrity.Mac = hex.EncodeToString(mac)
+	return nil
+}
+
+// verify derives the wallet key from passphrase and checks the integrity tag of the wallet contents.
+// The key is kept to sign the wallet when it is stored again.
+func (m *Metadata) verify(store Store, passphrase []byte) error {
+	key, err := crypto.DeriveKey(passphrase, m.Integrity.KDParams)
+	if err != nil {
+		return err
+	}
+	secret := crypto.NewSecret(key)
+
+	expected, err := m.mac(secret.Bytes(), store)
+	if err != nil {
+		secret.Wipe()
+		return err
+	}
+
+	mac, err := hex.DecodeString(m.Integrity.Mac)
+	if err != nil || !hmac.Equal(mac, expected) {
+		secret.Wipe()
+		m.tampered = true
+		return ErrTampered
+	}
+
+	m.key = secret
+	return nil
+}
+
+// mac returns the HMAC-SHA3-256 of the wallet metadata, sections and accounts.
+func (m *Metadata) mac(key []byte, store Store) ([]byte, error) {
+	// json encodes maps with sorted keys and compacts raw sections, so the encoding is canonical
+	content, err := json.Marshal(struct {
+		Version   int
+		Created   time.Time
+		NetworkID int8
+		KDParams  crypto.KDParams
+		Accounts  Store
+		Sections  map[string]json.RawMessage `json:",omitempty"`
+	}{m.Version, m.Created, m.NetworkID, m.Integrity.KDParams, store, m.Sections})
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha3.New256, key)
+	h.Write(content)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,142 @@
+package accounts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func passphrase(p string) func() []byte {
+	return func() []byte { return []byte(p) }
+}
+
+func TestWalletIntegrity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "accounts.json")
+
+	s := Store{}
+	_, err = s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	_, err = s.CreateAccount("bob", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	meta := NewMetadata()
+	assert.False(t, meta.IsProtected())
+	assert.NoError(t, meta.SetPassphrase([]byte("wallet"), crypto.DefaultCypherParams))
+	assert.True(t, meta.CheckPassphrase([]byte("wallet")))
+	assert.False(t, meta.CheckPassphrase([]byte("other")))
+	assert.NoError(t, StoreAccounts(path, &s, meta))
+
+	loaded, loadedMeta, err := LoadAccounts(path, passphrase("wallet"))
+	assert.NoError(t, err)
+	assert.Equal(t, s, *loaded)
+	assert.True(t, loadedMeta.IsProtected())
+	assert.False(t, loadedMeta.IsTampered())
+
+	// the loaded key keeps signing the wallet
+	assert.NoError(t, StoreAccounts(path, loaded, loadedMeta))
+	_, _, err = LoadAccounts(path, passphrase("wallet"))
+	assert.NoError(t, err)
+
+	_, wrongMeta, err := LoadAccounts(path, passphrase("other"))
+	assert.Equal(t, ErrTampered, err)
+	assert.True(t, wrongMeta.IsTampered())
+
+	// swap the public keys of both accounts
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	wallet := walletFile{}
+	assert.NoError(t, json.Unmarshal(data, &wallet))
+	alice, bob := wallet.Accounts["alice"], wallet.Accounts["bob"]
+	wallet.Accounts["alice"], wallet.Accounts["bob"] = bob, alice
+	data, err = json.Marshal(wallet)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	tampered, tamperedMeta, err := LoadAccounts(path, passphrase("wallet"))
+	assert.Equal(t, ErrTampered, err)
+	assert.True(t, tamperedMeta.IsTampered())
+	assert.Error(t, StoreAccounts(path, tampered, tamperedMeta), "tampered wallets should not be written")
+	assert.Error(t, tamperedMeta.SetPassphrase([]byte("wallet"), crypto.DefaultCypherParams))
+}
+
+func TestStrippedIntegrityTag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "accounts.json")
+
+	s := Store{}
+	_, err = s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	meta := NewMetadata()
+	assert.Equal(t, ErrUnprotected, StoreAccounts(path, &s, meta), "wallets are always protected")
+	assert.NoError(t, meta.SetPassphrase([]byte("wallet"), crypto.DefaultCypherParams))
+	assert.NoError(t, StoreAccounts(path, &s, meta))
+
+	// remove the integrity tag
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	fields := make(map[string]json.RawMessage)
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	delete(fields, "integrity")
+	data, err = json.Marshal(fields)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	stripped, strippedMeta, err := LoadAccounts(path, passphrase("wallet"))
+	assert.Equal(t, ErrTampered, err)
+	assert.True(t, strippedMeta.IsTampered())
+	assert.Equal(t, s, *stripped)
+	assert.Error(t, StoreAccounts(path, stripped, strippedMeta))
+}
+
+func TestWalletSections(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "accounts.json")
+
+	s := Store{}
+	meta := NewMetadata()
+	assert.NoError(t, meta.SetPassphrase([]byte("wallet"), crypto.DefaultCypherParams))
+	assert.NoError(t, meta.SetSection("limits", map[string]int{"alice": 10}))
+	assert.NoError(t, StoreAccounts(path, &s, meta))
+
+	_, loadedMeta, err := LoadAccounts(path, passphrase("wallet"))
+	assert.NoError(t, err)
+	limits := make(map[string]int)
+	ok, err := loadedMeta.Section("limits", &limits)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"alice": 10}, limits)
+	ok, err = loadedMeta.Section("other", &limits)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	// sections are authenticated like the accounts, removing one breaks the tag
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	fields := make(map[string]json.RawMessage)
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	delete(fields, "sections")
+	data, err = json.Marshal(fields)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	_, _, err = LoadAccounts(path, passphrase("wallet"))
+	assert.Equal(t, ErrTampered, err)
+
+	assert.NoError(t, meta.SetSection("limits", nil))
+	assert.NoError(t, StoreAccounts(path, &s, meta))
+	_, loadedMeta, err = LoadAccounts(path, passphrase("wallet"))
+	assert.NoError(t, err)
+	assert.Empty(t, loadedMeta.Sections)
+}
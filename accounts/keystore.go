@@ -0,0 +1,27 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Keystore is the portable form of a single account with its private key encrypted.
+type Keystore struct {
+	Alias string `json:"alias"`
+	AccountKeys
+}
+
+// ExportKeystore returns the keystore JSON of account name. Accounts whose private key is not encrypted yet must
+// be unlocked first, see UnlockAccount.
+func (s Store) ExportKeystore(name string) ([]byte, error) {
+	acc, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("account not found")
+	}
+	if acc.Crypto == nil {
+		return nil, fmt.Errorf("account `%s` private key is not encrypted", name)
+	}
+
+	acc.Archived = false
+	return json.Marshal(Keystore{name, acc})
+}
@@ -0,0 +1,89 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/libonomy/wallet-cli/os/filesystem"
+	"github.com/libonomy/wallet-cli/os/log"
+	"github.com/libonomy/wallet-cli/os/p2p/config"
+)
+
+// CurrentVersion is the wallet file format version written by StoreAccounts.
+// Version 1 files are a bare map of aliases to account keys without any envelope.
+const CurrentVersion = 3
+
+// migration upgrades raw wallet file contents by a single version.
+type migration func(data []byte) ([]byte, error)
+
+// migrations[i] upgrades a version i+1 file to version i+2.
+var migrations = []migration{
+	migrateV1ToV2,
+	migrateV2ToV3,
+}
+
+// fileVersion returns the format version of the raw wallet file contents.
+func fileVersion(data []byte) (int, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, err
+	}
+
+	// a version 1 file may hold an account aliased `version`, which is an object rather than a number
+	var version int
+	if raw, ok := fields["version"]; ok && json.Unmarshal(raw, &version) == nil {
+		if version < 1 {
+			return 0, fmt.Errorf("invalid wallet file version %d", version)
+		}
+		return version, nil
+	}
+	return 1, nil
+}
+
+// upgrade backs up the wallet file at path and migrates its contents from version to CurrentVersion. The upgraded
+// contents are returned, they are written to path once the wallet is stored with a wallet passphrase.
+func upgrade(path string, data []byte, version int) ([]byte, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := ioutil.WriteFile(backup, data, filesystem.OwnerReadWrite); err != nil {
+		return nil, fmt.Errorf("failed to back up wallet file before upgrade: %v", err)
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		var err error
+		if data, err = migrations[v-1](data); err != nil {
+			return nil, fmt.Errorf("failed to upgrade wallet file from version %d: %v", v, err)
+		}
+	}
+
+	log.Info("upgraded wallet file %s from version %d to %d, backup written to %s", path, version, CurrentVersion, backup)
+	return data, nil
+}
+
+// migrateV1ToV2 wraps the bare accounts map in the versioned envelope.
+func migrateV1ToV2(data []byte) ([]byte, error) {
+	accounts := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"version":   2,
+		"created":   time.Now().UTC(),
+		"networkId": config.ConfigValues.NetworkID,
+		"accounts":  accounts,
+	})
+}
+
+// migrateV2ToV3 only bumps the version. Version 3 adds the integrity tag, which version 2 readers would drop when
+// storing the wallet.
+func migrateV2ToV3(data []byte) ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	fields["version"] = json.RawMessage("3")
+	return json.Marshal(fields)
+}
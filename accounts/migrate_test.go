@@ -0,0 +1,89 @@
+package accounts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const v1Wallet = `{"alice":{"pubkey":"aa","privkey":"bb"},"version":{"pubkey":"cc","privkey":"dd"}}`
+
+func TestUpgradeV1Wallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "accounts.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(v1Wallet), 0600))
+
+	store, meta, err := LoadAccounts(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentVersion, meta.Version)
+	assert.False(t, meta.Created.IsZero())
+	assert.Equal(t, AccountKeys{PubKey: "aa", PrivKey: "bb"}, (*store)["alice"])
+	assert.Equal(t, AccountKeys{PubKey: "cc", PrivKey: "dd"}, (*store)["version"])
+
+	backup, err := ioutil.ReadFile(path + ".v1.bak")
+	assert.NoError(t, err)
+	assert.Equal(t, v1Wallet, string(backup))
+
+	assert.True(t, meta.IsLegacy())
+
+	// the upgraded contents are only written once the wallet is protected
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, v1Wallet, string(data))
+	assert.Equal(t, ErrUnprotected, StoreAccounts(path, store, meta))
+	assert.NoError(t, meta.SetPassphrase([]byte("wallet"), crypto.DefaultCypherParams))
+	assert.NoError(t, StoreAccounts(path, store, meta))
+	data, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	version, err := fileVersion(data)
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentVersion, version)
+
+	reloaded, reloadedMeta, err := LoadAccounts(path, passphrase("wallet"))
+	assert.NoError(t, err)
+	assert.Equal(t, store, reloaded)
+	assert.Equal(t, meta.Created.Unix(), reloadedMeta.Created.Unix())
+	assert.False(t, reloadedMeta.IsLegacy())
+}
+
+func TestStoreAndLoadAccounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := Store{}
+	_, err = s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "accounts.json")
+	meta := NewMetadata()
+	assert.NoError(t, meta.SetPassphrase([]byte("wallet"), crypto.DefaultCypherParams))
+	assert.NoError(t, StoreAccounts(path, &s, meta))
+
+	loaded, loadedMeta, err := LoadAccounts(path, passphrase("wallet"))
+	assert.NoError(t, err)
+	assert.Equal(t, s, *loaded)
+	assert.Equal(t, meta.NetworkID, loadedMeta.NetworkID)
+
+	_, err = os.Stat(path + ".v1.bak")
+	assert.True(t, os.IsNotExist(err), "current version files should not be backed up")
+}
+
+func TestRefuseNewerWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "accounts.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"version":99,"accounts":{},"future":true}`), 0600))
+
+	_, _, err = LoadAccounts(path, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package accounts
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// DefaultScheme is the key scheme of accounts stored without an explicit scheme.
+const DefaultScheme = "ed25519"
+
+// KeyScheme is a signature scheme account keys are generated and used with.
+type KeyScheme interface {
+	// Name returns the name the scheme is registered and stored with.
+	Name() string
+	// GenerateKey returns a new random key pair.
+	GenerateKey() (pub, priv []byte, err error)
+	// PublicKey returns the public key matching priv.
+	PublicKey(priv []byte) ([]byte, error)
+	Sign(priv, msg []byte) ([]byte, error)
+	Verify(pub, msg, sig []byte) bool
+	// Address derives the account address from pub.
+	Address(pub []byte) address.Address
+	// ParsePublicKey validates and returns the public key stored in s.
+	ParsePublicKey(s string) ([]byte, error)
+	// ParsePrivateKey validates and returns the private key stored in s.
+	ParsePrivateKey(s string) ([]byte, error)
+	// EncodeKey returns the stored string representation of a public or private key.
+	EncodeKey(key []byte) string
+	// Encrypt encrypts msg so that only the owner of the private key matching pub can decrypt it.
+	Encrypt(pub, msg []byte) ([]byte, error)
+	// Decrypt decrypts a message encrypted to the public key of priv.
+	Decrypt(priv, ciphertext []byte) ([]byte, error)
+}
+
+var schemes = make(map[string]KeyScheme)
+
+// RegisterScheme makes a key scheme available by its name.
+func RegisterScheme(s KeyScheme) {
+	schemes[s.Name()] = s
+}
+
+// GetScheme returns the key scheme registered as name. An empty name returns the default scheme.
+func GetScheme(name string) (KeyScheme, error) {
+	if name == "" {
+		name = DefaultScheme
+	}
+	if s, ok := schemes[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown key scheme `%s`", name)
+}
+
+// SchemeNames returns the sorted names of the registered key schemes accounts can be created with. HSMScheme shares
+// its keys with ed25519 and is only used by accounts kept in PKCS#11 tokens.
+func SchemeNames() []string {
+	lst := make([]string, 0, len(schemes))
+	for name := range schemes {
+		if name != HSMScheme {
+			lst = append(lst, name)
+		}
+	}
+	sort.Strings(lst)
+	return lst
+}
+
+// DetectScheme returns the single registered key scheme able to parse the hex encoded public key pub.
+func DetectScheme(pub string) (KeyScheme, error) {
+	var found KeyScheme
+	for _, name := range SchemeNames() {
+		if _, err := schemes[name].ParsePublicKey(pub); err == nil {
+			if found != nil {
+				return nil, fmt.Errorf("public key matches multiple key schemes")
+			}
+			found = schemes[name]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("public key does not match any key scheme")
+	}
+	return found, nil
+}
@@ -0,0 +1,83 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/libonomy/ed25519"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+func init() {
+	RegisterScheme(ed25519Scheme{})
+}
+
+// ed25519Scheme signs with libonomy ed25519 keys, the only scheme accepted for transactions.
+type ed25519Scheme struct{}
+
+func (ed25519Scheme) Name() string {
+	return "ed25519"
+}
+
+func (ed25519Scheme) GenerateKey() (pub, priv []byte, err error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+func (ed25519Scheme) PublicKey(priv []byte) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("expected %d bytes private key", ed25519.PrivateKeySize)
+	}
+	return []byte(ed25519.NewKeyFromSeed(priv[:ed25519.SeedSize]).Public().(ed25519.PublicKey)), nil
+}
+
+func (ed25519Scheme) Sign(priv, msg []byte) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("expected %d bytes private key", ed25519.PrivateKeySize)
+	}
+	return ed25519.Sign2(priv, msg), nil
+}
+
+func (ed25519Scheme) Verify(pub, msg, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify2(pub, msg, sig)
+}
+
+func (ed25519Scheme) Address(pub []byte) address.Address {
+	return address.BytesToAddress(pub)
+}
+
+func (ed25519Scheme) ParsePublicKey(s string) ([]byte, error) {
+	return decodeHexKey(s, ed25519.PublicKeySize)
+}
+
+func (ed25519Scheme) ParsePrivateKey(s string) ([]byte, error) {
+	return decodeHexKey(s, ed25519.PrivateKeySize)
+}
+
+func (ed25519Scheme) EncodeKey(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+// Encrypt seals msg to the X25519 key converted from pub, see sealX25519.
+func (ed25519Scheme) Encrypt(pub, msg []byte) ([]byte, error) {
+	return sealX25519(pub, msg)
+}
+
+func (ed25519Scheme) Decrypt(priv, ciphertext []byte) ([]byte, error) {
+	return openX25519(priv, ciphertext)
+}
+
+// decodeHexKey decodes a hex encoded key of exactly size bytes.
+func decodeHexKey(s string, size int) ([]byte, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != size {
+		return nil, fmt.Errorf("expected %d bytes key, got %d", size, len(key))
+	}
+	return key, nil
+}
@@ -0,0 +1,35 @@
+package accounts
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+func init() {
+	RegisterScheme(ed25519RFC8032Scheme{})
+}
+
+// ed25519RFC8032Scheme signs with standard RFC 8032 Ed25519 signatures, as produced by PKCS#11 tokens (CKM_EDDSA).
+// Keys and addresses are those of the ed25519 scheme, but ed25519.Sign2 signatures differ and only those are
+// accepted for transactions.
+type ed25519RFC8032Scheme struct {
+	ed25519Scheme
+}
+
+func (ed25519RFC8032Scheme) Name() string {
+	return HSMScheme
+}
+
+func (ed25519RFC8032Scheme) Sign(priv, msg []byte) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("expected %d bytes private key", ed25519.PrivateKeySize)
+	}
+	return ed25519.Sign(priv, msg), nil
+}
+
+func (ed25519RFC8032Scheme) Verify(pub, msg, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(pub, msg, sig)
+}
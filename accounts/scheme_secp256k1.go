@@ -0,0 +1,93 @@
+package accounts
+
+import (
+	"encoding/hex"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+func init() {
+	RegisterScheme(secp256k1Scheme{})
+}
+
+// secp256k1Scheme signs with btcec secp256k1 keys. Messages are hashed with SHA3-256 before signing.
+type secp256k1Scheme struct{}
+
+func (secp256k1Scheme) Name() string {
+	return "secp256k1"
+}
+
+func (secp256k1Scheme) GenerateKey() (pub, priv []byte, err error) {
+	privKey, pubKey, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	return pubKey.Bytes(), privKey.Bytes(), nil
+}
+
+func (secp256k1Scheme) PublicKey(priv []byte) ([]byte, error) {
+	privKey, err := crypto.NewPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return privKey.GetPublicKey().Bytes(), nil
+}
+
+func (secp256k1Scheme) Sign(priv, msg []byte) ([]byte, error) {
+	privKey, err := crypto.NewPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return privKey.Sign(crypto.Sha256(msg))
+}
+
+func (secp256k1Scheme) Verify(pub, msg, sig []byte) bool {
+	pubKey, err := crypto.NewPublicKey(pub)
+	if err != nil {
+		return false
+	}
+	ok, err := pubKey.Verify(crypto.Sha256(msg), sig)
+	return err == nil && ok
+}
+
+// Address returns the last 20 bytes of the Keccak256 hash of the compressed public key.
+func (secp256k1Scheme) Address(pub []byte) address.Address {
+	return address.BytesToAddress(crypto.Keccak256(pub))
+}
+
+func (secp256k1Scheme) ParsePublicKey(s string) ([]byte, error) {
+	pub, err := decodeHexKey(s, 33)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := crypto.NewPublicKey(pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+func (secp256k1Scheme) ParsePrivateKey(s string) ([]byte, error) {
+	return decodeHexKey(s, 32)
+}
+
+func (secp256k1Scheme) EncodeKey(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+// Encrypt encrypts msg to pub with btcec ECIES.
+func (secp256k1Scheme) Encrypt(pub, msg []byte) ([]byte, error) {
+	pubKey, err := crypto.NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pubKey.Encrypt(msg)
+}
+
+func (secp256k1Scheme) Decrypt(priv, ciphertext []byte) ([]byte, error) {
+	privKey, err := crypto.NewPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return privKey.Decrypt(ciphertext)
+}
@@ -0,0 +1,118 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemes(t *testing.T) {
+	assert.Equal(t, []string{"ed25519", "secp256k1"}, SchemeNames())
+
+	_, err := GetScheme("rsa")
+	assert.Error(t, err)
+
+	def, err := GetScheme("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultScheme, def.Name())
+
+	msg := []byte("libonomy")
+	for _, name := range SchemeNames() {
+		scheme, err := GetScheme(name)
+		assert.NoError(t, err)
+
+		pub, priv, err := scheme.GenerateKey()
+		assert.NoError(t, err, name)
+
+		derived, err := scheme.PublicKey(priv)
+		assert.NoError(t, err, name)
+		assert.Equal(t, pub, derived, name)
+
+		sig, err := scheme.Sign(priv, msg)
+		assert.NoError(t, err, name)
+		assert.True(t, scheme.Verify(pub, msg, sig), name)
+		assert.False(t, scheme.Verify(pub, []byte("other"), sig), name)
+
+		parsedPub, err := scheme.ParsePublicKey(scheme.EncodeKey(pub))
+		assert.NoError(t, err, name)
+		assert.Equal(t, pub, parsedPub, name)
+		parsedPriv, err := scheme.ParsePrivateKey(scheme.EncodeKey(priv))
+		assert.NoError(t, err, name)
+		assert.Equal(t, priv, parsedPriv, name)
+
+		detected, err := DetectScheme(scheme.EncodeKey(pub))
+		assert.NoError(t, err, name)
+		assert.Equal(t, name, detected.Name())
+	}
+}
+
+func TestHSMAccount(t *testing.T) {
+	std, err := GetScheme(HSMScheme)
+	assert.NoError(t, err)
+	pub, priv, err := std.GenerateKey()
+	assert.NoError(t, err)
+
+	// token signatures are standard Ed25519, which the ed25519 scheme (ed25519.Sign2) does not accept
+	msg := []byte("libonomy")
+	sig, err := std.Sign(priv, msg)
+	assert.NoError(t, err)
+	ed, err := GetScheme("ed25519")
+	assert.NoError(t, err)
+	assert.False(t, ed.Verify(pub, msg, sig))
+
+	s := Store{}
+	_, err = s.CreateAccount("file", HSMScheme, 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.Error(t, err, "token keys only")
+
+	key := HSMKey{Module: "/usr/lib/softhsm/libsofthsm2.so", Token: "ops", KeyID: "01"}
+	_, err = s.AddHSMAccount("hsm", HSMKey{Token: "ops", KeyID: "not hex"}, pub)
+	assert.Error(t, err)
+	created, err := s.AddHSMAccount("hsm", key, pub)
+	assert.NoError(t, err)
+	assert.True(t, created.IsLocked())
+	_, err = s.AddHSMAccount("hsm", key, pub)
+	assert.Error(t, err, "alias taken")
+
+	acc, err := s.GetAccount("hsm")
+	assert.NoError(t, err)
+	assert.Equal(t, HSMScheme, acc.Scheme.Name())
+	assert.Equal(t, ed.Address(pub), acc.Address())
+	assert.Contains(t, s.ListAccounts(), "hsm")
+
+	stored, ok := s.HSMKey("hsm")
+	assert.True(t, ok)
+	assert.Equal(t, key, *stored)
+	assert.False(t, s.IsEncrypted("hsm"))
+	assert.Error(t, s.UnlockAccount(acc, testPassphrase))
+}
+
+func TestAccountDispatch(t *testing.T) {
+	s := Store{}
+	for _, name := range SchemeNames() {
+		created, err := s.CreateAccount(name, name, 0, testPassphrase, crypto.DefaultCypherParams)
+		assert.NoError(t, err)
+
+		acc, err := s.GetAccount(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, acc.Scheme.Name())
+		assert.Equal(t, created.Address(), acc.Address())
+
+		addr, err := s.AccountAddress(name)
+		assert.NoError(t, err)
+		assert.Equal(t, acc.Address(), addr)
+
+		assert.NoError(t, s.UnlockAccount(acc, testPassphrase))
+		sig, err := acc.Sign([]byte("libonomy"))
+		assert.NoError(t, err)
+		assert.True(t, acc.Verify([]byte("libonomy"), sig))
+	}
+
+	// accounts stored before key schemes were introduced use ed25519
+	legacy := s["ed25519"]
+	legacy.Scheme = ""
+	s["legacy"] = legacy
+	acc, err := s.GetAccount("legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "ed25519", acc.Scheme.Name())
+}
@@ -0,0 +1,89 @@
+package accounts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// MatchAccounts returns the aliases out of aliases that are selected by query. The query is matched, in order, as
+// a 1-based index into the sorted aliases, an exact alias, an address, an alias prefix and a fuzzy pattern whose
+// characters appear in the alias in the same order. The first rule that matches anything decides the result.
+func (s Store) MatchAccounts(aliases []string, query string) []string {
+	sorted := append([]string(nil), aliases...)
+	sort.Strings(sorted)
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return sorted
+	}
+
+	if idx, err := strconv.Atoi(query); err == nil && idx >= 1 && idx <= len(sorted) {
+		return sorted[idx-1 : idx]
+	}
+
+	for _, alias := range sorted {
+		if alias == query {
+			return []string{alias}
+		}
+	}
+
+	if address.IsHexAddress(query) {
+		addr := address.HexToAddress(query)
+		for _, alias := range sorted {
+			if a, err := s.AccountAddress(alias); err == nil && a == addr {
+				return []string{alias}
+			}
+		}
+		return nil
+	}
+
+	lowerQuery := strings.ToLower(query)
+	matches := make([]string, 0)
+	for _, alias := range sorted {
+		if strings.HasPrefix(strings.ToLower(alias), lowerQuery) {
+			matches = append(matches, alias)
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+
+	for _, alias := range sorted {
+		if fuzzyMatch(strings.ToLower(alias), lowerQuery) {
+			matches = append(matches, alias)
+		}
+	}
+	return matches
+}
+
+// SelectAccount returns the single non archived account selected by query, see MatchAccounts.
+func (s Store) SelectAccount(query string) (*Account, error) {
+	matches := s.MatchAccounts(s.ListAccounts(), query)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no account matches `%s`", query)
+	case 1:
+		return s.GetAccount(matches[0])
+	default:
+		return nil, fmt.Errorf("`%s` matches multiple accounts: %s", query, strings.Join(matches, ", "))
+	}
+}
+
+// fuzzyMatch returns true iff all characters of sub appear in s in the same order.
+func fuzzyMatch(s, sub string) bool {
+	pattern := []rune(sub)
+	i := 0
+	for _, c := range s {
+		if i == len(pattern) {
+			break
+		}
+		if c == pattern[i] {
+			i++
+		}
+	}
+	return i == len(pattern)
+}
@@ -0,0 +1,40 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchAccounts(t *testing.T) {
+	s := Store{}
+	for _, alias := range []string{"treasury", "alice", "alfred", "bob"} {
+		_, err := s.CreateAccount(alias, "", 0, testPassphrase, crypto.DefaultCypherParams)
+		assert.NoError(t, err)
+	}
+	all := s.ListAccounts()
+	assert.Equal(t, []string{"alfred", "alice", "bob", "treasury"}, all)
+
+	assert.Equal(t, all, s.MatchAccounts(all, ""))
+	assert.Equal(t, []string{"bob"}, s.MatchAccounts(all, "3"))
+	assert.Equal(t, []string{"alfred", "alice"}, s.MatchAccounts(all, "al"))
+	assert.Equal(t, []string{"alice"}, s.MatchAccounts(all, "ALI"))
+	assert.Equal(t, []string{"treasury"}, s.MatchAccounts(all, "tsy"))
+	assert.Empty(t, s.MatchAccounts(all, "zed"))
+
+	addr, err := s.AccountAddress("bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, s.MatchAccounts(all, StringAddress(addr)))
+
+	acc, err := s.SelectAccount("tre")
+	assert.NoError(t, err)
+	assert.Equal(t, "treasury", acc.Name)
+
+	_, err = s.SelectAccount("al")
+	assert.Error(t, err, "expected ambiguous selection error")
+
+	assert.NoError(t, s.SetArchived("bob", true))
+	_, err = s.SelectAccount("bob")
+	assert.Error(t, err, "archived accounts should not be selectable")
+}
@@ -0,0 +1,141 @@
+package accounts
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/crypto/shamir"
+	"github.com/libonomy/wallet-cli/os/crypto/wordlist"
+)
+
+const (
+	keyShareVersion     = 1
+	keyShareHeaderLen   = 6 // version, threshold and fingerprint
+	keyShareChecksumLen = 4
+)
+
+// KeyShare is one share of an account private key split with Shamir's secret sharing.
+type KeyShare struct {
+	Threshold   int
+	Fingerprint [4]byte // first bytes of the account address, identifies shares of the same key
+	Share       []byte  // raw shamir share
+}
+
+// SplitKey splits the private key of the unlocked account acc into n shares, any threshold of which recover it.
+// The shares are verified to recover the account before they are returned.
+func SplitKey(acc *Account, n, threshold int) ([]*KeyShare, error) {
+	if acc.IsLocked() {
+		return nil, fmt.Errorf("account `%s` is locked", acc.Name)
+	}
+
+	raw, err := shamir.Split(acc.PrivKey.Bytes(), n, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	var fingerprint [4]byte
+	copy(fingerprint[:], acc.Address().Bytes())
+	shares := make([]*KeyShare, n)
+	for i, share := range raw {
+		shares[i] = &KeyShare{threshold, fingerprint, share}
+	}
+
+	_, priv, pub, err := RecoverKey(shares[:threshold])
+	if err != nil {
+		return nil, err
+	}
+	priv.Wipe()
+	if !bytes.Equal(pub, acc.PubKey) {
+		return nil, errors.New("shares do not recover the account key")
+	}
+
+	return shares, nil
+}
+
+// RecoverKey combines shares into a private key and returns it along with its key scheme and public key.
+// The key address must match the shares fingerprint.
+func RecoverKey(shares []*KeyShare) (KeyScheme, *crypto.Secret, []byte, error) {
+	if len(shares) == 0 {
+		return nil, nil, nil, errors.New("no shares given")
+	}
+	if len(shares) < shares[0].Threshold {
+		return nil, nil, nil, fmt.Errorf("%d shares are required, got %d", shares[0].Threshold, len(shares))
+	}
+
+	raw := make([][]byte, len(shares))
+	for i, s := range shares {
+		if s.Fingerprint != shares[0].Fingerprint || s.Threshold != shares[0].Threshold {
+			return nil, nil, nil, errors.New("shares belong to different keys")
+		}
+		raw[i] = s.Share
+	}
+
+	priv, err := shamir.Combine(raw)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key := crypto.NewSecret(priv)
+
+	for _, name := range SchemeNames() {
+		scheme, _ := GetScheme(name)
+		pub, err := scheme.PublicKey(key.Bytes())
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(scheme.Address(pub).Bytes()[:4], shares[0].Fingerprint[:]) {
+			return scheme, key, pub, nil
+		}
+	}
+
+	key.Wipe()
+	return nil, nil, nil, errors.New("recovered key does not match the shares fingerprint")
+}
+
+// bytes returns the binary share encoding: version, threshold, fingerprint, shamir share and checksum.
+func (s *KeyShare) bytes() []byte {
+	b := make([]byte, 0, keyShareHeaderLen+len(s.Share)+keyShareChecksumLen)
+	b = append(b, keyShareVersion, byte(s.Threshold))
+	b = append(b, s.Fingerprint[:]...)
+	b = append(b, s.Share...)
+	return append(b, crypto.Sha256(b)[:keyShareChecksumLen]...)
+}
+
+// Hex returns the share as a hex string.
+func (s *KeyShare) Hex() string {
+	return hex.EncodeToString(s.bytes())
+}
+
+// Words returns the share as space separated words, one per byte, see wordlist.
+func (s *KeyShare) Words() string {
+	return strings.Join(wordlist.Encode(s.bytes()), " ")
+}
+
+// ParseKeyShare decodes a share encoded by Hex or Words and validates its checksum.
+func ParseKeyShare(str string) (*KeyShare, error) {
+	str = strings.TrimSpace(str)
+	b, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		if b, err = wordlist.Decode(strings.Fields(str)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(b) < keyShareHeaderLen+2+keyShareChecksumLen {
+		return nil, errors.New("share is too short")
+	}
+	body, checksum := b[:len(b)-keyShareChecksumLen], b[len(b)-keyShareChecksumLen:]
+	if !bytes.Equal(crypto.Sha256(body)[:keyShareChecksumLen], checksum) {
+		return nil, errors.New("share checksum mismatch, check for typos")
+	}
+	if body[0] != keyShareVersion {
+		return nil, fmt.Errorf("unsupported share version %d", body[0])
+	}
+
+	s := &KeyShare{Threshold: int(body[1]), Share: append([]byte(nil), body[keyShareHeaderLen:]...)}
+	copy(s.Fingerprint[:], body[2:keyShareHeaderLen])
+	return s, nil
+}
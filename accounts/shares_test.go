@@ -0,0 +1,80 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAndRecoverKey(t *testing.T) {
+	s := Store{}
+	for _, name := range SchemeNames() {
+		acc, err := s.CreateAccount(name, name, 0, testPassphrase, crypto.DefaultCypherParams)
+		assert.NoError(t, err)
+
+		shares, err := SplitKey(acc, 5, 3)
+		assert.NoError(t, err)
+		assert.Len(t, shares, 5)
+
+		parsed := make([]*KeyShare, 0)
+		for i, share := range []*KeyShare{shares[4], shares[1], shares[2]} {
+			encoded := share.Hex()
+			if i%2 == 0 {
+				encoded = share.Words()
+			}
+			p, err := ParseKeyShare(encoded)
+			assert.NoError(t, err)
+			parsed = append(parsed, p)
+		}
+
+		scheme, priv, pub, err := RecoverKey(parsed)
+		assert.NoError(t, err)
+		assert.Equal(t, name, scheme.Name())
+		assert.Equal(t, acc.PubKey, pub)
+		assert.Equal(t, acc.PrivKey.Bytes(), priv.Bytes())
+		assert.Equal(t, acc.Address(), scheme.Address(pub))
+
+		_, _, _, err = RecoverKey(parsed[:2])
+		assert.Error(t, err, "expected not enough shares error")
+	}
+}
+
+func TestParseKeyShareChecksum(t *testing.T) {
+	s := Store{}
+	acc, err := s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	shares, err := SplitKey(acc, 2, 2)
+	assert.NoError(t, err)
+
+	encoded := []byte(shares[0].Hex())
+	if encoded[20] == 'a' {
+		encoded[20] = 'b'
+	} else {
+		encoded[20] = 'a'
+	}
+	_, err = ParseKeyShare(string(encoded))
+	assert.Error(t, err, "expected checksum error")
+
+	_, err = ParseKeyShare("not a share")
+	assert.Error(t, err)
+}
+
+func TestImportAccount(t *testing.T) {
+	s := Store{}
+	acc, err := s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	shares, err := SplitKey(acc, 3, 2)
+	assert.NoError(t, err)
+
+	scheme, priv, _, err := RecoverKey(shares[1:])
+	assert.NoError(t, err)
+	_, err = s.ImportAccount("alice", scheme, 0, priv, testPassphrase, crypto.DefaultCypherParams)
+	assert.Error(t, err, "expected duplicate alias error")
+
+	scheme, priv, _, err = RecoverKey(shares[1:])
+	assert.NoError(t, err)
+	imported, err := s.ImportAccount("restored", scheme, 0, priv, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	assert.Equal(t, acc.Address(), imported.Address())
+}
@@ -1,65 +1,255 @@
 package accounts
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/libonomy/ed25519"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/filesystem"
 	"github.com/libonomy/wallet-cli/os/log"
+	"github.com/libonomy/wallet-cli/os/p2p/config"
 )
 
 type AccountKeys struct {
-	PubKey  string `json:"pubkey"`
-	PrivKey string `json:"privkey"`
+	Scheme   string      `json:"scheme,omitempty"` // empty for DefaultScheme
+	PubKey   string      `json:"pubkey"`
+	PrivKey  string      `json:"privkey,omitempty"` // unencrypted private key of accounts created before encryption
+	Crypto   *CryptoData `json:"crypto,omitempty"`  // passphrase encrypted private key
+	Archived bool        `json:"archived,omitempty"`
+	VRF      *VRFKey     `json:"vrf,omitempty"`
+	HSM      *HSMKey     `json:"hsm,omitempty"` // token key of accounts without a stored private key
 }
 
 type Store map[string]AccountKeys
 
-func StoreAccounts(path string, store *Store) error {
-	w, err := os.Create(path)
+// Metadata describes a wallet file.
+type Metadata struct {
+	Version   int        `json:"version"`
+	Created   time.Time  `json:"created"`
+	NetworkID int8       `json:"networkId"`
+	Integrity *Integrity `json:"integrity,omitempty"`
+	// settings kept in the wallet file and authenticated with the accounts, by name, see Section
+	Sections map[string]json.RawMessage `json:"sections,omitempty"`
+
+	key      *crypto.Secret // wallet key the contents are authenticated with
+	tampered bool
+	legacy   bool // upgraded from a format without integrity tags
+}
+
+// walletFile is the envelope accounts are persisted in since version 2 of the wallet file format.
+type walletFile struct {
+	Metadata
+	Accounts Store `json:"accounts"`
+}
+
+// NewMetadata returns the metadata of a wallet created now for the configured network.
+func NewMetadata() *Metadata {
+	return &Metadata{
+		Version:   CurrentVersion,
+		Created:   time.Now().UTC(),
+		NetworkID: config.ConfigValues.NetworkID,
+	}
+}
+
+// Section decodes the wallet section name into v. It returns false if the wallet has no such section.
+func (m *Metadata) Section(name string, v interface{}) (bool, error) {
+	data, ok := m.Sections[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("invalid wallet section %s: %v", name, err)
+	}
+	return true, nil
+}
+
+// SetSection sets the wallet section name to the JSON encoding of v, or removes it if v is nil. Sections are stored
+// and authenticated with the accounts by StoreAccounts.
+func (m *Metadata) SetSection(name string, v interface{}) error {
+	if v == nil {
+		delete(m.Sections, name)
+		return nil
+	}
+	data, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(w)
-	defer w.Close()
-	if err := enc.Encode(store); err != nil {
-		return err
+	if m.Sections == nil {
+		m.Sections = make(map[string]json.RawMessage)
 	}
+	m.Sections[name] = data
 	return nil
 }
 
-func LoadAccounts(path string) (*Store, error) {
+// StoreAccounts persists store to path in the current wallet file format, authenticated with the wallet key. Wallets
+// without a wallet passphrase are refused with ErrUnprotected. The file is replaced atomically so a failed write never
+// leaves a truncated wallet behind.
+func StoreAccounts(path string, store *Store, meta *Metadata) error {
+	meta.Version = CurrentVersion
+	if err := meta.sign(*store); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(walletFile{*meta, *store}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadAccounts reads the wallet file at path. Files written in an older format are backed up and upgraded in memory,
+// see Metadata.IsLegacy, files written in a newer, unknown format are refused. passphrase is called to get the wallet
+// passphrase and the contents are verified. On verification failure, or if the integrity tag is missing, the accounts
+// are returned with ErrTampered.
+func LoadAccounts(path string, passphrase func() []byte) (*Store, *Metadata, error) {
 	_, err := os.Stat(path)
 	if os.IsNotExist(err) {
-		log.Warning("genesis config not lodad since file does not exist. file=%v", path)
-		return nil, err
+		log.Warning("accounts not loaded since file does not exist. file=%v", path)
+		return nil, nil, err
 	}
-	r, err := os.Open(path)
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("error opening file: %v", err)
+		return nil, nil, fmt.Errorf("error opening file: %v", err)
 	}
-	defer r.Close()
 
-	dec := json.NewDecoder(r)
-	cfg := &Store{}
-	err = dec.Decode(cfg)
+	version, err := fileVersion(data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if version > CurrentVersion {
+		return nil, nil, fmt.Errorf("wallet file version %d is newer than the supported version %d, please upgrade", version, CurrentVersion)
+	}
+	legacy := version < CurrentVersion
+	if legacy {
+		if data, err = upgrade(path, data, version); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	wallet := &walletFile{}
+	if err := json.Unmarshal(data, wallet); err != nil {
+		return nil, nil, err
+	}
+	if wallet.Accounts == nil {
+		wallet.Accounts = Store{}
+	}
+	wallet.legacy = legacy
+
+	if !wallet.IsProtected() && !legacy {
+		// current version files are always written with a tag
+		wallet.tampered = true
+		log.Error("%v. file=%v", ErrTampered, path)
+		return &wallet.Accounts, &wallet.Metadata, ErrTampered
+	}
+	if wallet.IsProtected() {
+		pass := passphrase()
+		defer crypto.Wipe(pass)
+		if err := wallet.verify(wallet.Accounts, pass); err != nil {
+			if err == ErrTampered {
+				log.Error("%v. file=%v", err, path)
+				return &wallet.Accounts, &wallet.Metadata, err
+			}
+			return nil, nil, err
+		}
 	}
 
-	return cfg, nil
+	return &wallet.Accounts, &wallet.Metadata, nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, filesystem.OwnerReadWrite); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
 }
 
-func (s Store) CreateAccount(alias string) *Account {
-	sPub, key, err := ed25519.GenerateKey(rand.Reader)
+// CreateAccount generates a new key pair using the key scheme named scheme and stores it as alias with the
+// private key encrypted by passphrase with kdParams and bound to network networkID. The returned account is unlocked.
+func (s Store) CreateAccount(alias, scheme string, networkID int8, passphrase []byte, kdParams crypto.KDParams) (*Account, error) {
+	keyScheme, err := GetScheme(scheme)
+	if err != nil {
+		return nil, err
+	}
+	_, priv, err := keyScheme.GenerateKey()
 	if err != nil {
 		log.Error("cannot create account: %s", err)
-		return nil
+		return nil, err
+	}
+
+	return s.ImportAccount(alias, keyScheme, networkID, crypto.NewSecret(priv), passphrase, kdParams)
+}
+
+// ImportAccount stores the private key priv of scheme as alias, encrypted by passphrase with kdParams and bound to
+// network networkID. The returned account is unlocked and owns priv.
+func (s Store) ImportAccount(alias string, scheme KeyScheme, networkID int8, priv *crypto.Secret, passphrase []byte, kdParams crypto.KDParams) (*Account, error) {
+	if _, ok := s[alias]; ok {
+		priv.Wipe()
+		return nil, fmt.Errorf("account `%s` already exists", alias)
+	}
+
+	if scheme.Name() == HSMScheme {
+		priv.Wipe()
+		return nil, fmt.Errorf("%s keys are kept in PKCS#11 tokens", HSMScheme)
+	}
+
+	pub, err := scheme.PublicKey(priv.Bytes())
+	if err != nil {
+		priv.Wipe()
+		return nil, err
+	}
+
+	keys := AccountKeys{Scheme: scheme.Name(), PubKey: scheme.EncodeKey(pub)}
+	if err := encryptKey(&keys, priv.Bytes(), passphrase, networkID, kdParams); err != nil {
+		priv.Wipe()
+		return nil, err
 	}
-	acc := &Account{Name: alias, PubKey: sPub, PrivKey: key}
-	s[alias] = AccountKeys{PubKey: hex.EncodeToString(sPub), PrivKey: hex.EncodeToString(key)}
-	return acc
+
+	s[alias] = keys
+	return &Account{Name: alias, Scheme: scheme, PubKey: pub, PrivKey: priv}, nil
+}
+
+// RenameAccount changes the alias of an existing account.
+func (s Store) RenameAccount(name, newName string) error {
+	acc, ok := s[name]
+	if !ok {
+		return fmt.Errorf("account not found")
+	}
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("account alias cannot be empty")
+	}
+	if _, ok := s[newName]; ok {
+		return fmt.Errorf("account `%s` already exists", newName)
+	}
+
+	delete(s, name)
+	s[newName] = acc
+	return nil
+}
+
+// SetArchived hides or restores an account in the accounts list. Archived accounts are kept in the store.
+func (s Store) SetArchived(name string, archived bool) error {
+	acc, ok := s[name]
+	if !ok {
+		return fmt.Errorf("account not found")
+	}
+
+	acc.Archived = archived
+	s[name] = acc
+	return nil
+}
+
+// DeleteAccount removes an account from the store.
+func (s Store) DeleteAccount(name string) error {
+	if _, ok := s[name]; !ok {
+		return fmt.Errorf("account not found")
+	}
+
+	delete(s, name)
+	return nil
 }
@@ -0,0 +1,143 @@
+package accounts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPassphrase = []byte("beagles")
+
+func TestMain(m *testing.M) {
+	// keep key derivation fast in tests
+	crypto.DefaultCypherParams.N = 1024
+	os.Exit(m.Run())
+}
+
+func TestRenameArchiveDelete(t *testing.T) {
+	s := Store{}
+	acc, err := s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	_, err = s.CreateAccount("bob", "secp256k1", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	assert.Error(t, s.RenameAccount("alice", "bob"), "expected duplicate alias error")
+	assert.Error(t, s.RenameAccount("carol", "dave"), "expected not found error")
+	assert.NoError(t, s.RenameAccount("alice", "carol"))
+	renamed, err := s.GetAccount("carol")
+	assert.NoError(t, err)
+	assert.Equal(t, acc.PubKey, renamed.PubKey)
+
+	assert.NoError(t, s.SetArchived("carol", true))
+	assert.Equal(t, []string{"bob"}, s.ListAccounts())
+	assert.Equal(t, []string{"carol"}, s.ListArchivedAccounts())
+	assert.NoError(t, s.SetArchived("carol", false))
+	assert.Len(t, s.ListAccounts(), 2)
+
+	assert.NoError(t, s.DeleteAccount("carol"))
+	assert.Error(t, s.DeleteAccount("carol"))
+	assert.Equal(t, []string{"bob"}, s.ListAccounts())
+}
+
+func TestTombstone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tombstones")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := Store{}
+	_, err = s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	path, err := s.WriteTombstone(dir, "alice", []byte("backup"), crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	_, _, err = RestoreTombstone(path, []byte("poodles"))
+	assert.Error(t, err, "expected wrong passphrase error")
+
+	tomb, keys, err := RestoreTombstone(path, []byte("backup"))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", tomb.Alias)
+	assert.Equal(t, s["alice"], *keys)
+
+	// the alias is kept in the file, not in its name
+	_, err = s.CreateAccount("../../bob", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	path, err = s.WriteTombstone(dir, "../../bob", []byte("backup"), crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	tomb, _, err = RestoreTombstone(path, []byte("backup"))
+	assert.NoError(t, err)
+	assert.Equal(t, "../../bob", tomb.Alias)
+}
+
+func TestUnlockAccount(t *testing.T) {
+	s := Store{}
+	created, err := s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	assert.False(t, created.IsLocked())
+	assert.True(t, s.IsEncrypted("alice"))
+	assert.Empty(t, s["alice"].PrivKey, "private key should not be stored in plain text")
+	priv := append([]byte(nil), created.PrivKey.Bytes()...)
+
+	acc, err := s.GetAccount("alice")
+	assert.NoError(t, err)
+	assert.True(t, acc.IsLocked())
+	_, err = acc.Sign([]byte("libonomy"))
+	assert.Error(t, err, "locked accounts cannot sign")
+
+	assert.Error(t, s.UnlockAccount(acc, []byte("poodles")))
+	assert.True(t, acc.IsLocked())
+	assert.NoError(t, s.UnlockAccount(acc, testPassphrase))
+	assert.Equal(t, priv, acc.PrivKey.Bytes())
+
+	key := acc.PrivKey.Bytes()
+	acc.Lock()
+	assert.True(t, acc.IsLocked())
+	assert.Equal(t, make([]byte, len(key)), key, "private key should be wiped on lock")
+}
+
+func TestUnlockLegacyAccount(t *testing.T) {
+	scheme, err := GetScheme("")
+	assert.NoError(t, err)
+	pub, priv, err := scheme.GenerateKey()
+	assert.NoError(t, err)
+
+	s := Store{"legacy": AccountKeys{PubKey: scheme.EncodeKey(pub), PrivKey: scheme.EncodeKey(priv)}}
+	assert.False(t, s.IsEncrypted("legacy"))
+
+	acc, err := s.GetAccount("legacy")
+	assert.NoError(t, err)
+	assert.NoError(t, s.UnlockAccount(acc, testPassphrase))
+	assert.Equal(t, priv, acc.PrivKey.Bytes())
+	assert.False(t, s.IsEncrypted("legacy"))
+
+	assert.NoError(t, s.EncryptAccount(acc, 0, testPassphrase, crypto.DefaultCypherParams))
+	assert.True(t, s.IsEncrypted("legacy"))
+	assert.Empty(t, s["legacy"].PrivKey)
+
+	acc.Lock()
+	assert.Error(t, s.UnlockAccount(acc, []byte("poodles")))
+	assert.NoError(t, s.UnlockAccount(acc, testPassphrase))
+}
+
+func TestChangePassphrase(t *testing.T) {
+	s := Store{}
+	created, err := s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	priv := append([]byte(nil), created.PrivKey.Bytes()...)
+	newPassphrase := []byte("tiger zebra mango cloud panda orbit")
+
+	assert.Error(t, s.ChangePassphrase("alice", []byte("poodles"), newPassphrase, crypto.DefaultCypherParams))
+	assert.Error(t, s.ChangePassphrase("bob", testPassphrase, newPassphrase, crypto.DefaultCypherParams))
+	assert.NoError(t, s.ChangePassphrase("alice", testPassphrase, newPassphrase, crypto.DefaultCypherParams))
+
+	acc, err := s.GetAccount("alice")
+	assert.NoError(t, err)
+	assert.Error(t, s.UnlockAccount(acc, testPassphrase))
+	assert.NoError(t, s.UnlockAccount(acc, newPassphrase))
+	assert.Equal(t, priv, acc.PrivKey.Bytes())
+}
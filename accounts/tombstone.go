@@ -0,0 +1,93 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/filesystem"
+)
+
+// Tombstone is an encrypted backup of a deleted account.
+type Tombstone struct {
+	Alias   string      `json:"alias"`
+	PubKey  string      `json:"pubkey"`
+	Deleted time.Time   `json:"deleted"`
+	Crypto  *CryptoData `json:"crypto"`
+}
+
+// WriteTombstone encrypts the keys of account name with passphrase and kdParams and writes them to a
+// new file in dir, named after the account address since aliases may hold any character. It returns the path of the
+// written file.
+func (s Store) WriteTombstone(dir, name string, passphrase []byte, kdParams crypto.KDParams) (string, error) {
+	acc, ok := s[name]
+	if !ok {
+		return "", fmt.Errorf("account not found")
+	}
+	addr, err := s.AccountAddress(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(acc)
+	if err != nil {
+		return "", err
+	}
+	defer crypto.Wipe(data)
+
+	c, err := Encrypt(data, passphrase, tombstoneAD(acc.PubKey), kdParams)
+	if err != nil {
+		return "", err
+	}
+
+	t := Tombstone{Alias: name, PubKey: acc.PubKey, Deleted: time.Now().UTC(), Crypto: c}
+	bytes, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := filesystem.GetFullDirectoryPath(dir); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%x-%d.json", addr.Bytes(), t.Deleted.Unix()))
+	if err := ioutil.WriteFile(path, bytes, filesystem.OwnerReadWrite); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// RestoreTombstone decrypts the account keys stored in the tombstone at path.
+func RestoreTombstone(path string, passphrase []byte) (*Tombstone, *AccountKeys, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := &Tombstone{}
+	if err := json.Unmarshal(bytes, t); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := Decrypt(t.Crypto, passphrase, tombstoneAD(t.PubKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer crypto.Wipe(data)
+
+	keys := &AccountKeys{}
+	if err := json.Unmarshal(data, keys); err != nil {
+		return nil, nil, err
+	}
+
+	return t, keys, nil
+}
+
+// tombstoneAD returns the associated data binding a tombstone to the public key of the deleted account.
+func tombstoneAD(pubKey string) []byte {
+	return []byte("libonomy-tombstone:" + strings.ToLower(pubKey))
+}
@@ -0,0 +1,120 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// VanityPattern describes a wanted address. Prefix and suffix are matched case sensitively against the checksummed
+// address hex, without the 0x prefix.
+type VanityPattern struct {
+	Prefix string
+	Suffix string
+}
+
+// Validate returns an error if the pattern cannot match any address.
+func (p VanityPattern) Validate() error {
+	if p.Prefix == "" && p.Suffix == "" {
+		return errors.New("a prefix or a suffix is required")
+	}
+	if len(p.Prefix)+len(p.Suffix) > 2*len(address.Address{}) {
+		return errors.New("pattern is longer than an address")
+	}
+	for _, c := range p.Prefix + p.Suffix {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return errors.New("pattern may only contain hex characters")
+		}
+	}
+	return nil
+}
+
+// Matches returns true iff the checksummed hex of addr matches the pattern.
+func (p VanityPattern) Matches(addr address.Address) bool {
+	hex := addr.Hex()[2:]
+	return strings.HasPrefix(hex, p.Prefix) && strings.HasSuffix(hex, p.Suffix)
+}
+
+// Difficulty returns the expected number of keys to generate until one matches. Every hex digit divides the odds
+// by 16 and every letter by another 2, since the checksum sets its case at random.
+func (p VanityPattern) Difficulty() float64 {
+	d := 1.0
+	for _, c := range p.Prefix + p.Suffix {
+		d *= 16
+		if c > '9' {
+			d *= 2
+		}
+	}
+	return d
+}
+
+// Probability returns the chance to have found a match after attempts keys.
+func (p VanityPattern) Probability(attempts uint64) float64 {
+	return 1 - math.Pow(1-1/p.Difficulty(), float64(attempts))
+}
+
+// GenerateVanity generates keys of scheme on workers goroutines until one has an address matching pattern or ctx is
+// done. attempts is atomically increased with every generated key so callers can report progress.
+func GenerateVanity(ctx context.Context, scheme KeyScheme, pattern VanityPattern, workers int, attempts *uint64) ([]byte, *crypto.Secret, error) {
+	if err := pattern.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		pub  []byte
+		priv *crypto.Secret
+		err  error
+	}
+	found := make(chan result, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				pub, priv, err := scheme.GenerateKey()
+				if err != nil {
+					found <- result{err: err}
+					return
+				}
+				atomic.AddUint64(attempts, 1)
+
+				if pattern.Matches(scheme.Address(pub)) {
+					found <- result{pub: pub, priv: crypto.NewSecret(priv)}
+					return
+				}
+				crypto.Wipe(priv)
+			}
+		}()
+	}
+
+	var res result
+	select {
+	case res = <-found:
+	case <-ctx.Done():
+		res.err = ctx.Err()
+	}
+	cancel()
+	wg.Wait()
+
+	// other workers may have found a match at the same time
+	close(found)
+	for extra := range found {
+		extra.priv.Wipe()
+	}
+
+	return res.pub, res.priv, res.err
+}
@@ -0,0 +1,43 @@
+package accounts
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVanityPattern(t *testing.T) {
+	assert.Error(t, VanityPattern{}.Validate())
+	assert.Error(t, VanityPattern{Prefix: "xyz"}.Validate())
+	assert.Error(t, VanityPattern{Prefix: strings.Repeat("a", 41)}.Validate())
+	assert.NoError(t, VanityPattern{Prefix: "aB", Suffix: "12"}.Validate())
+
+	assert.Equal(t, float64(16*16), VanityPattern{Prefix: "12"}.Difficulty())
+	assert.Equal(t, float64(16*32), VanityPattern{Prefix: "1", Suffix: "f"}.Difficulty())
+	assert.InDelta(t, 0.5, VanityPattern{Prefix: "1"}.Probability(11), 0.05)
+}
+
+func TestGenerateVanity(t *testing.T) {
+	scheme, err := GetScheme("")
+	assert.NoError(t, err)
+
+	pattern := VanityPattern{Prefix: "0", Suffix: "A"}
+	var attempts uint64
+	pub, priv, err := GenerateVanity(context.Background(), scheme, pattern, 4, &attempts)
+	assert.NoError(t, err)
+	assert.True(t, pattern.Matches(scheme.Address(pub)))
+	assert.True(t, attempts > 0)
+
+	derived, err := scheme.PublicKey(priv.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, pub, derived)
+
+	// an impossible pattern runs until cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err = GenerateVanity(ctx, scheme, VanityPattern{Prefix: strings.Repeat("f", 40)}, 2, &attempts)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
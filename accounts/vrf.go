@@ -0,0 +1,101 @@
+package accounts
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+)
+
+// VRFKey is the VRF key pair of an account. The private key is encrypted with the account passphrase.
+type VRFKey struct {
+	PubKey string      `json:"pubkey"`
+	Crypto *CryptoData `json:"crypto"`
+}
+
+// vrfAD returns the associated data binding an encrypted VRF key to its public key, to the account owning it and to
+// the network of the account key.
+func vrfAD(accountPubKey, vrfPubKey string, networkID int8) []byte {
+	return []byte(fmt.Sprintf("libonomy-vrf-key:%s:%s:%d", strings.ToLower(accountPubKey), strings.ToLower(vrfPubKey), networkID))
+}
+
+// encryptVRFKey encrypts the VRF private key priv of the account with keys, bound to the network of the account key.
+func encryptVRFKey(keys AccountKeys, vrfPubKey string, priv, passphrase []byte, kdParams crypto.KDParams) (*CryptoData, error) {
+	networkID := keys.Crypto.NetworkID
+	c, err := Encrypt(priv, passphrase, vrfAD(keys.PubKey, vrfPubKey, networkID), kdParams)
+	if err != nil {
+		return nil, err
+	}
+	c.NetworkID = networkID
+	return c, nil
+}
+
+// GenerateVRFKey generates a VRF key pair for account name and stores its private key encrypted with the account
+// passphrase and kdParams. Any previous VRF key of the account is replaced. It returns the VRF public key.
+func (s Store) GenerateVRFKey(name string, passphrase []byte, kdParams crypto.KDParams) ([]byte, error) {
+	acc, err := s.GetAccount(name)
+	if err != nil {
+		return nil, err
+	}
+	if !s.IsEncrypted(name) {
+		return nil, fmt.Errorf("account `%s` is stored unencrypted, unlock it to encrypt it", name)
+	}
+	// make sure the VRF key is encrypted with the account passphrase
+	if err := s.UnlockAccount(acc, passphrase); err != nil {
+		return nil, err
+	}
+	acc.Lock()
+
+	pub, priv, err := crypto.GenerateVRFKeys()
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(priv)
+
+	keys := s[name]
+	pubHex := hex.EncodeToString(pub)
+	c, err := encryptVRFKey(keys, pubHex, priv, passphrase, kdParams)
+	if err != nil {
+		return nil, err
+	}
+
+	keys.VRF = &VRFKey{PubKey: pubHex, Crypto: c}
+	s[name] = keys
+	return pub, nil
+}
+
+// VRFPublicKey returns the VRF public key of account name.
+func (s Store) VRFPublicKey(name string) ([]byte, error) {
+	keys, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("account not found")
+	}
+	if keys.VRF == nil {
+		return nil, fmt.Errorf("account `%s` has no VRF key", name)
+	}
+	return hex.DecodeString(keys.VRF.PubKey)
+}
+
+// UnlockVRFKey decrypts the VRF private key of account name using passphrase.
+func (s Store) UnlockVRFKey(name string, passphrase []byte) (*crypto.Secret, error) {
+	pub, err := s.VRFPublicKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := s[name]
+	priv, err := Decrypt(keys.VRF.Crypto, passphrase, vrfAD(keys.PubKey, keys.VRF.PubKey, keys.VRF.Crypto.NetworkID))
+	if err != nil {
+		return nil, err
+	}
+	key := crypto.NewSecret(priv)
+
+	derived, err := crypto.VRFPublicKey(key.Bytes())
+	if err != nil || !bytes.Equal(derived, pub) {
+		key.Wipe()
+		return nil, fmt.Errorf("VRF private key does not match account `%s` VRF public key", name)
+	}
+	return key, nil
+}
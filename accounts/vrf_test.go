@@ -0,0 +1,86 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVRFKey(t *testing.T) {
+	s := Store{}
+	_, err := s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	_, err = s.VRFPublicKey("alice")
+	assert.Error(t, err)
+	_, err = s.GenerateVRFKey("alice", []byte("poodles"), crypto.DefaultCypherParams)
+	assert.Error(t, err, "VRF key must be encrypted with the account passphrase")
+
+	pub, err := s.GenerateVRFKey("alice", testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	stored, err := s.VRFPublicKey("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, pub, stored)
+
+	_, err = s.UnlockVRFKey("alice", []byte("poodles"))
+	assert.Error(t, err)
+	key, err := s.UnlockVRFKey("alice", testPassphrase)
+	assert.NoError(t, err)
+	defer key.Wipe()
+
+	output, proof, err := crypto.NewVRFSigner(key.Bytes()).Prove([]byte("msg"))
+	assert.NoError(t, err)
+	verified, err := crypto.VerifyVRF([]byte("msg"), proof, pub)
+	assert.NoError(t, err)
+	assert.Equal(t, output, verified)
+
+	// the VRF key follows the account when renamed
+	assert.NoError(t, s.RenameAccount("alice", "bob"))
+	_, err = s.UnlockVRFKey("bob", testPassphrase)
+	assert.NoError(t, err)
+}
+
+func TestVRFKeyNetwork(t *testing.T) {
+	s := Store{}
+	_, err := s.CreateAccount("alice", "", 7, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	_, err = s.GenerateVRFKey("alice", testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(7), s["alice"].VRF.Crypto.NetworkID, "the VRF key is bound to the network of the account key")
+
+	// rebinding the key to another network fails authentication
+	s["alice"].VRF.Crypto.NetworkID = 1
+	_, err = s.UnlockVRFKey("alice", testPassphrase)
+	assert.Error(t, err)
+	s["alice"].VRF.Crypto.NetworkID = 7
+	key, err := s.UnlockVRFKey("alice", testPassphrase)
+	assert.NoError(t, err)
+	key.Wipe()
+
+	assert.NoError(t, s.ChangePassphrase("alice", testPassphrase, []byte("poodles"), crypto.DefaultCypherParams))
+	assert.Equal(t, int8(7), s["alice"].VRF.Crypto.NetworkID)
+	key, err = s.UnlockVRFKey("alice", []byte("poodles"))
+	assert.NoError(t, err)
+	key.Wipe()
+}
+
+func TestChangePassphraseReencryptsVRFKey(t *testing.T) {
+	s := Store{}
+	_, err := s.CreateAccount("alice", "", 0, testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	pub, err := s.GenerateVRFKey("alice", testPassphrase, crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.ChangePassphrase("alice", testPassphrase, []byte("poodles"), crypto.DefaultCypherParams))
+	_, err = s.UnlockVRFKey("alice", testPassphrase)
+	assert.Error(t, err)
+	key, err := s.UnlockVRFKey("alice", []byte("poodles"))
+	assert.NoError(t, err)
+	defer key.Wipe()
+
+	_, proof, err := crypto.NewVRFSigner(key.Bytes()).Prove([]byte("msg"))
+	assert.NoError(t, err)
+	_, err = crypto.VerifyVRF([]byte("msg"), proof, pub)
+	assert.NoError(t, err)
+}
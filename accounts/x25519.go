@@ -0,0 +1,157 @@
+package accounts
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/libonomy/ed25519"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// x25519Info separates keys derived for ed25519 account encryption from any other use of the shared secret.
+	x25519Info = "libonomy-x25519-xchacha20poly1305"
+	// poly1305TagSize is the size of the authentication tag appended by XChaCha20-Poly1305.
+	poly1305TagSize = 16
+)
+
+// curve25519P is the field prime 2^255 - 19.
+var curve25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// x25519PublicKey converts an ed25519 public key to the equivalent X25519 public key, u = (1 + y) / (1 - y).
+func x25519PublicKey(pub []byte) ([]byte, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key length")
+	}
+
+	// y is encoded little endian with the sign of x in the top bit
+	le := make([]byte, 32)
+	copy(le, pub)
+	le[31] &= 0x7f
+	y := new(big.Int).SetBytes(reverse(le))
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+
+	den := new(big.Int).Sub(big.NewInt(1), y)
+	den.Mod(den, curve25519P)
+	if den.Sign() == 0 {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, den.ModInverse(den, curve25519P))
+	u.Mod(u, curve25519P)
+
+	out := make([]byte, 32)
+	b := u.Bytes()
+	copy(out[32-len(b):], b)
+	return reverse(out), nil
+}
+
+// x25519PrivateKey returns the X25519 scalar of an ed25519 private key, the clamped hash of its seed.
+func x25519PrivateKey(priv []byte) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid ed25519 private key length")
+	}
+
+	digest := sha512.Sum512(priv[:ed25519.SeedSize])
+	defer crypto.Wipe(digest[:])
+	scalar := make([]byte, 32)
+	copy(scalar, digest[:32])
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+	return scalar, nil
+}
+
+// sealX25519 encrypts msg to the ed25519 public key pub with an ephemeral X25519 key exchange and
+// XChaCha20-Poly1305. The output is the ephemeral public key, the nonce and the sealed message.
+func sealX25519(pub, msg []byte) ([]byte, error) {
+	recipient, err := x25519PublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := crypto.GetRandomBytes(curve25519.ScalarSize)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(ephemeral)
+	ephemeralPub, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := x25519AEAD(ephemeral, recipient, ephemeralPub, recipient)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := crypto.GetRandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(append([]byte{}, ephemeralPub...), nonce...)
+	return aead.Seal(out, nonce, msg, ephemeralPub), nil
+}
+
+// openX25519 decrypts a message sealed by sealX25519 with the ed25519 private key priv.
+func openX25519(priv, sealed []byte) ([]byte, error) {
+	if len(sealed) < curve25519.PointSize+chacha20poly1305.NonceSizeX+poly1305TagSize {
+		return nil, errors.New("encrypted message too short")
+	}
+	ephemeralPub := sealed[:curve25519.PointSize]
+	nonce := sealed[curve25519.PointSize : curve25519.PointSize+chacha20poly1305.NonceSizeX]
+
+	scalar, err := x25519PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(scalar)
+	recipient, err := curve25519.X25519(scalar, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := x25519AEAD(scalar, ephemeralPub, ephemeralPub, recipient)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := aead.Open(nil, nonce, sealed[curve25519.PointSize+chacha20poly1305.NonceSizeX:], ephemeralPub)
+	if err != nil {
+		return nil, errors.New("message not encrypted to this account or corrupted")
+	}
+	return msg, nil
+}
+
+// x25519AEAD returns the cipher keyed by the X25519 shared secret of scalar and peer, bound to the ephemeral and
+// recipient public keys.
+func x25519AEAD(scalar, peer, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	shared, err := curve25519.X25519(scalar, peer)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(shared)
+
+	key := make([]byte, chacha20poly1305.KeySize)
+	defer crypto.Wipe(key)
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(x25519Info)), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+func reverse(b []byte) []byte {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
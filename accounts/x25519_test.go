@@ -0,0 +1,57 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestX25519KeyConversion(t *testing.T) {
+	scheme, err := GetScheme("ed25519")
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		pub, priv, err := scheme.GenerateKey()
+		assert.NoError(t, err)
+
+		// the converted public key must match the public key of the converted private key
+		scalar, err := x25519PrivateKey(priv)
+		assert.NoError(t, err)
+		expected, err := curve25519.X25519(scalar, curve25519.Basepoint)
+		assert.NoError(t, err)
+		converted, err := x25519PublicKey(pub)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, converted)
+	}
+
+	_, err = x25519PublicKey(make([]byte, 31))
+	assert.Error(t, err)
+}
+
+func TestSchemeEncryptDecrypt(t *testing.T) {
+	msg := []byte("meet me at the usual place")
+	for _, name := range SchemeNames() {
+		scheme, err := GetScheme(name)
+		assert.NoError(t, err)
+		pub, priv, err := scheme.GenerateKey()
+		assert.NoError(t, err)
+		_, otherPriv, err := scheme.GenerateKey()
+		assert.NoError(t, err)
+
+		ciphertext, err := scheme.Encrypt(pub, msg)
+		assert.NoError(t, err, name)
+		assert.NotContains(t, string(ciphertext), string(msg))
+
+		decrypted, err := scheme.Decrypt(priv, ciphertext)
+		assert.NoError(t, err, name)
+		assert.Equal(t, msg, decrypted, name)
+
+		_, err = scheme.Decrypt(otherPriv, ciphertext)
+		assert.Error(t, err, name)
+
+		ciphertext[len(ciphertext)-1] ^= 1
+		_, err = scheme.Decrypt(priv, ciphertext)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,180 @@
+package approval
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/client"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	crypto.DefaultCypherParams.N = 1024
+	os.Exit(m.Run())
+}
+
+func sign(acc *accounts.Account, msg []byte) ([]byte, error) {
+	return acc.Sign(msg)
+}
+
+func newAccounts(t *testing.T, schemes ...string) []*accounts.Account {
+	s := accounts.Store{}
+	accs := make([]*accounts.Account, len(schemes))
+	for i, scheme := range schemes {
+		acc, err := s.CreateAccount(string(rune('a'+i)), scheme, 0, []byte("beagles"), crypto.DefaultCypherParams)
+		assert.NoError(t, err)
+		accs[i] = acc
+	}
+	return accs
+}
+
+func newPolicy(threshold int, accs ...*accounts.Account) *Policy {
+	p := &Policy{Threshold: threshold}
+	for _, acc := range accs {
+		p.Approvers = append(p.Approvers, Approver{Name: acc.Name, Scheme: acc.Scheme.Name(), PubKey: acc.Scheme.EncodeKey(acc.PubKey)})
+	}
+	return p
+}
+
+func newEnvelope(from *accounts.Account) *Envelope {
+	tx := client.InnerSerializableSignedTransaction{
+		AccountNonce: 3,
+		Recipient:    address.HexToAddress("0x1f2e3d4c5b6a79880796a5b4c3d2e1f001020304"),
+		GasLimit:     100,
+		Price:        1,
+		Amount:       1000000,
+	}
+	return NewEnvelope(1, from.Address(), tx, "invoice 42")
+}
+
+func TestThreshold(t *testing.T) {
+	accs := newAccounts(t, "ed25519", "secp256k1", "ed25519", "ed25519", "ed25519")
+	treasury, team, outsider := accs[0], accs[1:4], accs[4]
+
+	p := newPolicy(2, team...)
+	p.Account = accounts.StringAddress(treasury.Address())
+	assert.NoError(t, p.Validate())
+
+	e := newEnvelope(treasury)
+	_, err := p.Check(e)
+	assert.Error(t, err, "no approvals")
+
+	for _, acc := range []*accounts.Account{team[0], team[0], outsider} {
+		a, err := e.Approve(acc, sign)
+		assert.NoError(t, err)
+		assert.NoError(t, e.Add(a))
+	}
+	assert.Len(t, e.Approvals, 2, "approvals are replaced per approver")
+	approved, err := p.Check(e)
+	assert.Error(t, err, "one approver and an outsider")
+	assert.Equal(t, []string{team[0].Name}, approved)
+
+	a, err := e.Approve(team[1], sign)
+	assert.NoError(t, err)
+	assert.NoError(t, e.Add(a))
+	approved, err = p.Check(e)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{team[0].Name, team[1].Name}, approved)
+
+	// approvals do not carry over to a changed transaction
+	e.Tx.Amount++
+	_, err = p.Check(e)
+	assert.Error(t, err)
+	e.Tx.Amount--
+	e.Memo = "invoice 43"
+	_, err = p.Check(e)
+	assert.Error(t, err)
+	e.Memo = "invoice 42"
+
+	// policies stored in the wallet are checked from their JSON encoding
+	data, err := json.Marshal(p)
+	assert.NoError(t, err)
+	approved, err = e.CheckPolicy(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{team[0].Name, team[1].Name}, approved)
+	_, err = e.CheckPolicy([]byte(`{"threshold":1}`))
+	assert.Error(t, err, "invalid policy")
+
+	// the policy applies to a single sending account
+	p.Account = accounts.StringAddress(outsider.Address())
+	_, err = p.Check(e)
+	assert.Error(t, err)
+}
+
+func TestAddRejectsInvalid(t *testing.T) {
+	accs := newAccounts(t, "ed25519", "ed25519")
+	e := newEnvelope(accs[0])
+	other := newEnvelope(accs[0])
+	other.Tx.Amount = 1
+
+	a, err := other.Approve(accs[1], sign)
+	assert.NoError(t, err)
+	assert.Error(t, e.Add(a), "approval of another transaction")
+
+	a, err = e.Approve(accs[1], sign)
+	assert.NoError(t, err)
+	forged := *a
+	forged.PubKey = accs[0].Scheme.EncodeKey(accs[0].PubKey)
+	assert.Error(t, e.Add(&forged), "signature by another key")
+	assert.NoError(t, e.Add(a))
+
+	// an approval claiming the approver key is only counted if its signature verifies with the policy key
+	p := newPolicy(1, accs[0])
+	e.Approvals = append(e.Approvals, &forged)
+	_, err = p.Check(e)
+	assert.Error(t, err)
+}
+
+func TestPolicyValidate(t *testing.T) {
+	accs := newAccounts(t, "ed25519", "ed25519")
+	assert.Error(t, newPolicy(0, accs...).Validate())
+	assert.Error(t, newPolicy(3, accs...).Validate())
+	assert.Error(t, newPolicy(1, accs[0], accs[0]).Validate(), "duplicate approver")
+
+	p := newPolicy(1, accs...)
+	p.Approvers[1].PubKey = "00"
+	assert.Error(t, p.Validate())
+}
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "approval")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	accs := newAccounts(t, "ed25519", "ed25519", "secp256k1")
+	e := newEnvelope(accs[0])
+	envPath := filepath.Join(dir, "payment"+EnvelopeExt)
+	assert.NoError(t, e.Write(envPath))
+
+	read, err := ReadEnvelope(envPath)
+	assert.NoError(t, err)
+	assert.Equal(t, e.Tx, read.Tx)
+	digest, err := e.Digest()
+	assert.NoError(t, err)
+	readDigest, err := read.Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, digest, readDigest)
+
+	for _, acc := range accs[1:] {
+		a, err := read.Approve(acc, sign)
+		assert.NoError(t, err)
+		path := filepath.Join(dir, acc.Name+ApprovalExt)
+		assert.NoError(t, a.Write(path))
+		a, err = ReadApproval(path)
+		assert.NoError(t, err)
+		assert.NoError(t, e.Add(a))
+	}
+
+	policyPath := filepath.Join(dir, "policy.json")
+	assert.NoError(t, writeJSON(policyPath, newPolicy(2, accs[1:]...)))
+	p, err := ReadPolicy(policyPath)
+	assert.NoError(t, err)
+	_, err = p.Check(e)
+	assert.NoError(t, err)
+}
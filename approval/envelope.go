@@ -0,0 +1,206 @@
+// Package approval implements M-of-N approval of transactions. An envelope carries an unsigned transaction, team
+// members approve it with detached approvals signed by their accounts, and a policy defines whose approvals count
+// and how many are required before the sending account signs and broadcasts the transaction.
+package approval
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/client"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// File name extensions of envelopes and detached approvals.
+const (
+	EnvelopeExt = ".envelope.json"
+	ApprovalExt = ".approval.json"
+)
+
+// digestPrefix separates approval digests from transaction signatures and other signed messages.
+const digestPrefix = "libonomy-tx-approval-v1\x00"
+
+// Envelope is an unsigned transaction of account From collecting approvals.
+type Envelope struct {
+	NetworkID int8                                      `json:"networkId"`
+	From      string                                    `json:"from"` // address of the sending account
+	Tx        client.InnerSerializableSignedTransaction `json:"tx"`
+	Memo      string                                    `json:"memo,omitempty"`
+	Created   time.Time                                 `json:"created"` // informational, not covered by approvals
+	Approvals []*Approval                               `json:"approvals"`
+}
+
+// Approval is a detached approval of an envelope digest.
+type Approval struct {
+	Digest    string    `json:"digest"` // hex encoded
+	Scheme    string    `json:"scheme"`
+	Approver  string    `json:"approver"` // address of the approving account
+	PubKey    string    `json:"pubkey"`
+	Signature string    `json:"signature"`
+	Created   time.Time `json:"created"` // informational, not covered by the signature
+}
+
+// NewEnvelope returns an envelope of tx sent by from on network networkID.
+func NewEnvelope(networkID int8, from address.Address, tx client.InnerSerializableSignedTransaction, memo string) *Envelope {
+	return &Envelope{
+		NetworkID: networkID,
+		From:      accounts.StringAddress(from),
+		Tx:        tx,
+		Memo:      memo,
+		Created:   time.Now().UTC(),
+		Approvals: []*Approval{},
+	}
+}
+
+// Sender returns the address of the sending account.
+func (e *Envelope) Sender() (address.Address, error) {
+	if !address.IsHexAddress(e.From) {
+		return address.Address{}, fmt.Errorf("invalid sender address %s", e.From)
+	}
+	return address.HexToAddress(e.From), nil
+}
+
+// Network returns the network the transaction is for.
+func (e *Envelope) Network() int8 {
+	return e.NetworkID
+}
+
+// Transaction returns the unsigned transaction.
+func (e *Envelope) Transaction() client.InnerSerializableSignedTransaction {
+	return e.Tx
+}
+
+// Digest returns the digest approvers sign: SHA3-256 of a domain prefix, the network, the sender address, the XDR
+// encoded transaction and the memo.
+func (e *Envelope) Digest() ([]byte, error) {
+	from, err := e.Sender()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := client.InterfaceToBytes(&e.Tx)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sha256([]byte(digestPrefix), []byte{byte(e.NetworkID)}, from.Bytes(), tx, []byte(e.Memo)), nil
+}
+
+// Approve returns an approval of the envelope signed by acc with sign, e.g. WalletBE.Sign.
+func (e *Envelope) Approve(acc *accounts.Account, sign func(*accounts.Account, []byte) ([]byte, error)) (*Approval, error) {
+	digest, err := e.Digest()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign(acc, digest)
+	if err != nil {
+		return nil, err
+	}
+	return &Approval{
+		Digest:    hex.EncodeToString(digest),
+		Scheme:    acc.Scheme.Name(),
+		Approver:  accounts.StringAddress(acc.Address()),
+		PubKey:    acc.Scheme.EncodeKey(acc.PubKey),
+		Signature: hex.EncodeToString(sig),
+		Created:   time.Now().UTC(),
+	}, nil
+}
+
+// Add verifies a and adds it to the envelope, replacing any previous approval of the same approver.
+func (e *Envelope) Add(a *Approval) error {
+	if err := e.verify(a); err != nil {
+		return err
+	}
+	for i, prev := range e.Approvals {
+		if strings.EqualFold(prev.PubKey, a.PubKey) && prev.Scheme == a.Scheme {
+			e.Approvals[i] = a
+			return nil
+		}
+	}
+	e.Approvals = append(e.Approvals, a)
+	return nil
+}
+
+// verify checks that a is a valid approval of the envelope by the key it carries.
+func (e *Envelope) verify(a *Approval) error {
+	scheme, err := accounts.GetScheme(a.Scheme)
+	if err != nil {
+		return err
+	}
+	pub, err := scheme.ParsePublicKey(a.PubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	return e.verifyWith(a, scheme, pub)
+}
+
+// verifyWith checks that a is a valid approval of the envelope by public key pub of scheme.
+func (e *Envelope) verifyWith(a *Approval, scheme accounts.KeyScheme, pub []byte) error {
+	digest, err := e.Digest()
+	if err != nil {
+		return err
+	}
+	if expected, err := hex.DecodeString(a.Digest); err != nil || !bytes.Equal(expected, digest) {
+		return fmt.Errorf("approval by %s is for another transaction", a.Approver)
+	}
+	sig, err := hex.DecodeString(a.Signature)
+	if err != nil || !scheme.Verify(pub, digest, sig) {
+		return fmt.Errorf("approval by %s has an invalid signature", a.Approver)
+	}
+	return nil
+}
+
+// ReadEnvelope reads an envelope from path.
+func ReadEnvelope(path string) (*Envelope, error) {
+	e := &Envelope{}
+	if err := readJSON(path, e); err != nil {
+		return nil, err
+	}
+	if _, err := e.Sender(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Write writes the envelope to path.
+func (e *Envelope) Write(path string) error {
+	return writeJSON(path, e)
+}
+
+// ReadApproval reads a detached approval from path.
+func ReadApproval(path string) (*Approval, error) {
+	a := &Approval{}
+	if err := readJSON(path, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Write writes the approval to path.
+func (a *Approval) Write(path string) error {
+	return writeJSON(path, a)
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid file %s: %v", path, err)
+	}
+	return nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
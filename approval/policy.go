@@ -0,0 +1,134 @@
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// Approver is a team account whose approvals count.
+type Approver struct {
+	Name   string `json:"name"`
+	Scheme string `json:"scheme,omitempty"` // empty for accounts.DefaultScheme
+	PubKey string `json:"pubkey"`
+}
+
+// Policy requires Threshold approvals out of Approvers before a transaction of Account is broadcast.
+type Policy struct {
+	Account   string     `json:"account,omitempty"` // sending address the policy applies to, any if empty
+	Threshold int        `json:"threshold"`
+	Approvers []Approver `json:"approvers"`
+}
+
+// ReadPolicy reads and validates the policy at path.
+func ReadPolicy(path string) (*Policy, error) {
+	p := &Policy{}
+	if err := readJSON(path, p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %v", path, err)
+	}
+	return p, nil
+}
+
+// ParsePolicy decodes and validates a JSON policy, e.g. stored by WalletBE.SetApprovalPolicy.
+func ParsePolicy(data []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	return p, nil
+}
+
+// CheckPolicy decodes the JSON policy and checks e against it, see Policy.Check. It lets WalletBE.TransferApproved
+// check envelopes against the policies stored in the wallet.
+func (e *Envelope) CheckPolicy(policy []byte) ([]string, error) {
+	p, err := ParsePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	return p.Check(e)
+}
+
+// Validate checks the policy is satisfiable and lists each approver once.
+func (p *Policy) Validate() error {
+	if p.Account != "" && !address.IsHexAddress(p.Account) {
+		return fmt.Errorf("invalid account address %s", p.Account)
+	}
+	if p.Threshold < 1 || p.Threshold > len(p.Approvers) {
+		return fmt.Errorf("threshold must be between 1 and the %d approvers", len(p.Approvers))
+	}
+
+	seen := make(map[string]bool)
+	for _, a := range p.Approvers {
+		if strings.TrimSpace(a.Name) == "" {
+			return fmt.Errorf("approver without a name")
+		}
+		scheme, err := accounts.GetScheme(a.Scheme)
+		if err != nil {
+			return err
+		}
+		pub, err := scheme.ParsePublicKey(a.PubKey)
+		if err != nil {
+			return fmt.Errorf("invalid public key of approver `%s`: %v", a.Name, err)
+		}
+		addr := scheme.Address(pub).Hex()
+		if seen[addr] {
+			return fmt.Errorf("approver `%s` is listed twice", a.Name)
+		}
+		seen[addr] = true
+	}
+	return nil
+}
+
+// Check returns the names of the approvers with a valid approval in e. It fails if the policy does not apply to the
+// sender of e or if fewer than Threshold approvers approved.
+func (p *Policy) Check(e *Envelope) ([]string, error) {
+	from, err := e.Sender()
+	if err != nil {
+		return nil, err
+	}
+	if p.Account != "" && address.HexToAddress(p.Account) != from {
+		return nil, fmt.Errorf("the policy applies to account %s, not to %s", p.Account, e.From)
+	}
+
+	approved := make([]string, 0, len(p.Approvers))
+	for _, approver := range p.Approvers {
+		if p.approved(approver, e) {
+			approved = append(approved, approver.Name)
+		}
+	}
+	if len(approved) < p.Threshold {
+		return approved, fmt.Errorf("%d of %d required approvals", len(approved), p.Threshold)
+	}
+	return approved, nil
+}
+
+// approved reports whether e holds a valid approval of approver, verified with the key of the policy.
+func (p *Policy) approved(approver Approver, e *Envelope) bool {
+	scheme, err := accounts.GetScheme(approver.Scheme)
+	if err != nil {
+		return false
+	}
+	pub, err := scheme.ParsePublicKey(approver.PubKey)
+	if err != nil {
+		return false
+	}
+	for _, a := range e.Approvals {
+		if a.Scheme != scheme.Name() {
+			continue
+		}
+		if key, err := scheme.ParsePublicKey(a.PubKey); err == nil && bytes.Equal(key, pub) && e.verifyWith(a, scheme, pub) == nil {
+			return true
+		}
+	}
+	return false
+}
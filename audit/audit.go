@@ -0,0 +1,269 @@
+// Package audit records wallet events in an append-only, tamper-evident log file. Each entry holds the SHA3 hash of
+// the previous one, so modified or deleted entries break the chain. Checkpoint entries signed by a wallet key prevent
+// rewriting the whole chain up to them, and the last checkpoint kept by the wallet reveals removed checkpoints, see
+// Log.Checkpoint and Verify.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/filesystem"
+)
+
+const (
+	// FileName is the name of the audit log file in the logs directory.
+	FileName = "audit.log"
+
+	// CheckpointEvent is the event of checkpoint entries.
+	CheckpointEvent = "checkpoint"
+
+	// CheckpointInterval is the number of entries after which a checkpoint should be signed.
+	CheckpointInterval = 16
+
+	checkpointPrefix = "libonomy-audit-checkpoint-v1\x00"
+)
+
+// Entry is a recorded event.
+type Entry struct {
+	Seq        uint64            `json:"seq"`
+	Time       time.Time         `json:"time"`
+	Event      string            `json:"event"`
+	Account    string            `json:"account,omitempty"` // address of the account concerned, if any
+	Details    map[string]string `json:"details,omitempty"`
+	Checkpoint *Checkpoint       `json:"checkpoint,omitempty"`
+	Prev       string            `json:"prev"` // hash of the previous entry, empty for the first entry
+	Hash       string            `json:"hash"` // hash of this entry
+}
+
+// Checkpoint is the signature of the chain up to a checkpoint entry by a wallet key.
+type Checkpoint struct {
+	Scheme    string `json:"scheme"`
+	PubKey    string `json:"pubkey"`    // encoded by the key scheme
+	Signature string `json:"signature"` // hex signature of the checkpoint message, see CheckpointMessage
+}
+
+// Head identifies the last checkpoint entry of a log. Wallets keep it in their authenticated contents so that Verify
+// detects a truncated or deleted log.
+type Head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Signer is a key trusted to sign checkpoints.
+type Signer struct {
+	Scheme string `json:"scheme"`
+	PubKey string `json:"pubkey"` // encoded by the key scheme
+}
+
+// ComputeHash returns the hex SHA3-256 hash of the entry, excluding its Hash field.
+func (e *Entry) ComputeHash() (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(crypto.Sha256(data)), nil
+}
+
+// CheckpointMessage returns the message signed by a checkpoint following the entry hashed prev.
+func CheckpointMessage(prev string) ([]byte, error) {
+	h, err := hex.DecodeString(prev)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry hash: %v", err)
+	}
+	return crypto.Sha256([]byte(checkpointPrefix), h), nil
+}
+
+// Log appends entries to a log file, one JSON entry per line.
+type Log struct {
+	path string
+	mu   sync.Mutex
+
+	// state of the file after the last entry read or written, reloaded when the file changes
+	size     int64
+	seq      uint64
+	hash     string
+	unsigned int // entries since the last checkpoint
+}
+
+// Open returns the audit log stored in dir.
+func Open(dir string) *Log {
+	return OpenFile(filepath.Join(dir, FileName))
+}
+
+// OpenFile returns the audit log stored at path.
+func OpenFile(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the log file path.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append records event concerning account, which may be empty.
+func (l *Log) Append(event, account string, details map[string]string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.append(&Entry{Event: event, Account: account, Details: details})
+}
+
+// Unsigned returns the number of entries since the last checkpoint.
+func (l *Log) Unsigned() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.load(); err != nil {
+		return 0, err
+	}
+	return l.unsigned, nil
+}
+
+// Checkpoint appends and returns a checkpoint entry of account, signed by sign with its key pub of scheme. It does
+// nothing and returns nil if the log is empty or already ends with a checkpoint.
+func (l *Log) Checkpoint(account, scheme, pub string, sign func(msg []byte) ([]byte, error)) (*Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	if l.unsigned == 0 {
+		return nil, nil
+	}
+	msg, err := CheckpointMessage(l.hash)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign(msg)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		Event:      CheckpointEvent,
+		Account:    account,
+		Checkpoint: &Checkpoint{Scheme: scheme, PubKey: pub, Signature: hex.EncodeToString(sig)},
+	}
+	if err := l.append(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// append chains e to the last entry and writes it. Callers hold l.mu.
+func (l *Log) append(e *Entry) error {
+	if err := l.load(); err != nil {
+		return err
+	}
+
+	e.Seq, e.Prev = l.seq+1, l.hash
+	if l.hash == "" {
+		e.Seq = 0
+	}
+	e.Time = time.Now().UTC()
+	hash, err := e.ComputeHash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, filesystem.OwnerReadWrite)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	l.size += int64(len(data) + 1)
+	l.seq, l.hash = e.Seq, e.Hash
+	if e.Event == CheckpointEvent {
+		l.unsigned = 0
+	} else {
+		l.unsigned++
+	}
+	return nil
+}
+
+// load reads the last entry of the log file if the file changed since it was last read or written, e.g. by another
+// wallet process. Callers hold l.mu.
+func (l *Log) load() error {
+	info, err := os.Stat(l.path)
+	if os.IsNotExist(err) {
+		l.size, l.seq, l.hash, l.unsigned = 0, 0, "", 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == l.size && l.hash != "" {
+		return nil
+	}
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	l.size, l.seq, l.hash, l.unsigned = 0, 0, "", 0
+	err = scan(f, func(line []byte, e *Entry, err error) {
+		l.size += int64(len(line) + 1)
+		if err != nil {
+			return
+		}
+		l.seq, l.hash = e.Seq, e.Hash
+		if e.Event == CheckpointEvent {
+			l.unsigned = 0
+		} else {
+			l.unsigned++
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if l.size != info.Size() {
+		return fmt.Errorf("audit log %s does not end with a complete entry", l.path)
+	}
+	return nil
+}
+
+// scan calls fn with each non-empty line of r and the entry it holds.
+func scan(r io.Reader, fn func(line []byte, e *Entry, err error)) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		line := s.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			fn(line, nil, fmt.Errorf("empty line"))
+			continue
+		}
+		e := &Entry{}
+		if err := json.Unmarshal(line, e); err != nil {
+			fn(line, nil, err)
+			continue
+		}
+		fn(line, e, nil)
+	}
+	return s.Err()
+}
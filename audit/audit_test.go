@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLog(t *testing.T) (*Log, *accounts.Account, func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	kdf := crypto.DefaultCypherParams
+	crypto.DefaultCypherParams.N = 1024
+	defer func() { crypto.DefaultCypherParams = kdf }()
+	acc, err := accounts.Store{}.CreateAccount("alice", "ed25519", 0, []byte("beagles"), crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	return Open(dir), acc, func() { os.RemoveAll(dir) }
+}
+
+func checkpoint(l *Log, acc *accounts.Account) error {
+	_, err := l.Checkpoint(accounts.StringAddress(acc.Address()), acc.Scheme.Name(), acc.Scheme.EncodeKey(acc.PubKey), acc.Sign)
+	return err
+}
+
+func signers(accs ...*accounts.Account) []Signer {
+	s := make([]Signer, len(accs))
+	for i, acc := range accs {
+		s[i] = Signer{Scheme: acc.Scheme.Name(), PubKey: acc.Scheme.EncodeKey(acc.PubKey)}
+	}
+	return s
+}
+
+func lines(t *testing.T, l *Log) []string {
+	data, err := ioutil.ReadFile(l.Path())
+	assert.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func writeLines(t *testing.T, l *Log, lines []string) {
+	assert.NoError(t, ioutil.WriteFile(l.Path(), []byte(strings.Join(lines, "\n")+"\n"), 0600))
+}
+
+func TestLogChain(t *testing.T) {
+	l, acc, cleanup := testLog(t)
+	defer cleanup()
+
+	n, err := l.Unsigned()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, checkpoint(l, acc), "nothing to sign")
+	_, err = Verify(l.Path(), signers(acc), nil)
+	assert.True(t, os.IsNotExist(err))
+
+	for _, event := range []string{"sign", "textsign", "transfer"} {
+		assert.NoError(t, l.Append(event, accounts.StringAddress(acc.Address()), map[string]string{"alias": "alice"}))
+	}
+	assert.NoError(t, checkpoint(l, acc))
+	assert.NoError(t, checkpoint(l, acc), "already signed")
+	assert.NoError(t, l.Append("export", "", nil))
+
+	// another process appending is picked up
+	other := Open(filepath.Dir(l.Path()))
+	assert.NoError(t, other.Append("account-create", "", nil))
+	assert.NoError(t, l.Append("account-rename", "", nil))
+	n, err = l.Unsigned()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	r, err := Verify(l.Path(), signers(acc), nil)
+	assert.NoError(t, err)
+	assert.True(t, r.OK(), r.Problems)
+	assert.Equal(t, 7, r.Entries)
+	assert.Len(t, r.Checkpoints, 1)
+	assert.Equal(t, uint64(3), r.Checkpoints[0].Seq)
+	assert.Equal(t, 3, r.Unsigned)
+}
+
+func TestVerifyTampering(t *testing.T) {
+	l, acc, cleanup := testLog(t)
+	defer cleanup()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, l.Append("sign", "", map[string]string{"digest": "00"}))
+	}
+	assert.NoError(t, checkpoint(l, acc))
+	assert.NoError(t, l.Append("transfer", "", map[string]string{"amount": "10"}))
+	orig := lines(t, l)
+
+	tampered := map[string][]string{
+		"deleted":   append(append([]string{}, orig[:1]...), orig[2:]...),
+		"first":     orig[1:],
+		"modified":  append([]string{strings.Replace(orig[0], `"00"`, `"01"`, 1)}, orig[1:]...),
+		"malformed": append([]string{orig[0], "{"}, orig[1:]...),
+		"swapped":   append([]string{orig[1], orig[0]}, orig[2:]...),
+	}
+	for name, lines := range tampered {
+		writeLines(t, l, lines)
+		r, err := Verify(l.Path(), signers(acc), nil)
+		assert.NoError(t, err, name)
+		assert.False(t, r.OK(), name)
+	}
+
+	// rewriting the chain before a checkpoint does not match its signature
+	writeLines(t, l, orig[:2])
+	assert.NoError(t, l.Append("sign", "", map[string]string{"digest": "ff"}))
+	assert.NoError(t, l.Append("sign", "", map[string]string{"digest": "00"}))
+	rewritten := append(lines(t, l), orig[4:]...)
+	writeLines(t, l, rewritten)
+	r, err := Verify(l.Path(), signers(acc), nil)
+	assert.NoError(t, err)
+	assert.False(t, r.OK())
+	assert.Empty(t, r.Checkpoints)
+
+	// removing the entries after the last checkpoint is only visible as the absence of unsigned entries
+	writeLines(t, l, orig[:5])
+	r, err = Verify(l.Path(), signers(acc), nil)
+	assert.NoError(t, err)
+	assert.True(t, r.OK(), r.Problems)
+	assert.Equal(t, 0, r.Unsigned)
+
+	// the last checkpoint kept by the wallet reveals removed checkpoints and deleted logs
+	head := &Head{Seq: 4}
+	for _, line := range orig {
+		e := &Entry{}
+		assert.NoError(t, json.Unmarshal([]byte(line), e))
+		if e.Seq == head.Seq {
+			head.Hash = e.Hash
+		}
+	}
+	writeLines(t, l, orig)
+	r, err = Verify(l.Path(), signers(acc), head)
+	assert.NoError(t, err)
+	assert.True(t, r.OK(), r.Problems)
+	writeLines(t, l, orig[:4])
+	r, err = Verify(l.Path(), signers(acc), head)
+	assert.NoError(t, err)
+	assert.False(t, r.OK(), "truncated before the checkpoint")
+	assert.NoError(t, os.Remove(l.Path()))
+	r, err = Verify(l.Path(), signers(acc), head)
+	assert.NoError(t, err)
+	assert.False(t, r.OK(), "deleted")
+
+	// a truncated last entry is not chained to
+	assert.NoError(t, ioutil.WriteFile(l.Path(), []byte(orig[0]+"\n"+orig[1][:10]), 0600))
+	assert.Error(t, l.Append("sign", "", nil))
+}
+
+func TestVerifyUnknownSigner(t *testing.T) {
+	l, acc, cleanup := testLog(t)
+	defer cleanup()
+	_, other, cleanupOther := testLog(t)
+	defer cleanupOther()
+
+	assert.NoError(t, l.Append("sign", "", nil))
+	assert.NoError(t, checkpoint(l, other))
+
+	// a log rewritten and signed with a fresh key does not verify
+	r, err := Verify(l.Path(), signers(acc), nil)
+	assert.NoError(t, err)
+	assert.False(t, r.OK())
+	assert.Empty(t, r.Checkpoints)
+
+	r, err = Verify(l.Path(), signers(acc, other), nil)
+	assert.NoError(t, err)
+	assert.True(t, r.OK(), r.Problems)
+	assert.Len(t, r.Checkpoints, 1)
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/libonomy/wallet-cli/accounts"
+)
+
+// Report is the result of verifying an audit log.
+type Report struct {
+	Entries     int
+	Checkpoints []*Entry // checkpoint entries with a valid signature
+	Unsigned    int      // entries after the last valid checkpoint
+	Problems    []string // deleted, modified or malformed entries and invalid checkpoints
+}
+
+// OK reports whether no problem was found.
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify checks the hash chain and the checkpoint signatures of the audit log stored at path. Checkpoints count only
+// when signed by one of signers. If head is not nil, the log must still hold that checkpoint: a missing, truncated or
+// rewritten log is reported as a problem. Otherwise entries removed from the end of the log are only detected when
+// they precede a checkpoint, so the number of entries after the last checkpoint is reported.
+func Verify(path string, signers []Signer, head *Head) (*Report, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && head != nil {
+		r := &Report{}
+		r.problem("the audit log was deleted, it held a checkpoint at entry %d", head.Seq)
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &Report{}
+	var prev *Entry
+	headFound := false
+	line := 0
+	err = scan(f, func(_ []byte, e *Entry, err error) {
+		line++
+		if err != nil {
+			r.problem("line %d: malformed entry: %v", line, err)
+			return
+		}
+		r.Entries++
+
+		problems := len(r.Problems)
+		switch {
+		case prev == nil && e.Seq != 0:
+			r.problem("entries 0 to %d are missing", e.Seq-1)
+		case prev != nil && e.Seq > prev.Seq+1:
+			r.problem("entries %d to %d are missing", prev.Seq+1, e.Seq-1)
+		case prev != nil && e.Seq <= prev.Seq:
+			r.problem("entry %d follows entry %d", e.Seq, prev.Seq)
+		case prev != nil && e.Prev != prev.Hash, prev == nil && e.Prev != "":
+			r.problem("entry %d does not follow the previous entry", e.Seq)
+		}
+		if hash, err := e.ComputeHash(); err != nil || hash != e.Hash {
+			r.problem("entry %d was modified", e.Seq)
+		}
+		if head != nil && e.Seq == head.Seq && e.Hash == head.Hash {
+			headFound = true
+		}
+
+		// a checkpoint only covers the entries it is chained to
+		if e.Event != CheckpointEvent {
+			r.Unsigned++
+		} else if err := verifyCheckpoint(e, signers); err != nil {
+			r.problem("entry %d: invalid checkpoint: %v", e.Seq, err)
+			r.Unsigned++
+		} else if len(r.Problems) > problems {
+			r.Unsigned++
+		} else {
+			r.Checkpoints = append(r.Checkpoints, e)
+			r.Unsigned = 0
+		}
+		prev = e
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if head != nil && !headFound {
+		if prev == nil || prev.Seq < head.Seq {
+			r.problem("entries up to %d were deleted, the wallet recorded a checkpoint at entry %d", head.Seq, head.Seq)
+		} else {
+			r.problem("the checkpoint at entry %d recorded by the wallet was replaced", head.Seq)
+		}
+	}
+	return r, nil
+}
+
+func (r *Report) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// verifyCheckpoint checks the signature of the checkpoint entry e, that it was made by its account and that the
+// key is one of signers.
+func verifyCheckpoint(e *Entry, signers []Signer) error {
+	c := e.Checkpoint
+	if c == nil {
+		return fmt.Errorf("no signature")
+	}
+	scheme, err := accounts.GetScheme(c.Scheme)
+	if err != nil {
+		return err
+	}
+	pub, err := scheme.ParsePublicKey(c.PubKey)
+	if err != nil {
+		return err
+	}
+	if accounts.StringAddress(scheme.Address(pub)) != e.Account {
+		return fmt.Errorf("the key is not the key of account %s", e.Account)
+	}
+	if !trusted(scheme, pub, signers) {
+		return fmt.Errorf("signed by %s, which is not a wallet account", e.Account)
+	}
+	sig, err := hex.DecodeString(c.Signature)
+	if err != nil {
+		return err
+	}
+	msg, err := CheckpointMessage(e.Prev)
+	if err != nil {
+		return err
+	}
+	if !scheme.Verify(pub, msg, sig) {
+		return fmt.Errorf("bad signature")
+	}
+	return nil
+}
+
+// trusted reports whether the key pub of scheme is one of signers.
+func trusted(scheme accounts.KeyScheme, pub []byte, signers []Signer) bool {
+	for _, signer := range signers {
+		s, err := accounts.GetScheme(signer.Scheme)
+		if err != nil || s.Name() != scheme.Name() {
+			continue
+		}
+		if key, err := s.ParsePublicKey(signer.PubKey); err == nil && bytes.Equal(key, pub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(acc *accounts.Account, msg []byte) ([]byte, error) {
+	return acc.Sign(msg)
+}
+
+func TestMain(m *testing.M) {
+	crypto.DefaultCypherParams.N = 1024
+	os.Exit(m.Run())
+}
+
+func newAccount(t *testing.T, scheme string) *accounts.Account {
+	s := accounts.Store{}
+	acc, err := s.CreateAccount("alice", scheme, 0, []byte("beagles"), crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	return acc
+}
+
+func getChallenge(t *testing.T, url string) *Challenge {
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	c := &Challenge{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(c))
+	return c
+}
+
+func login(t *testing.T, url string, r *Response) int {
+	body, err := json.Marshal(r)
+	assert.NoError(t, err)
+	resp, err := http.Post(url+"/login", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestChallengeEncoding(t *testing.T) {
+	c, err := NewChallenge("https://app.example", "", time.Minute)
+	assert.NoError(t, err)
+
+	parsed, err := ParseChallenge(c.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, c.Message(), parsed.Message())
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+	parsed, err = ParseChallenge(string(data))
+	assert.NoError(t, err)
+	assert.Equal(t, c.Message(), parsed.Message())
+
+	_, err = ParseChallenge(`{"origin":"https://app.example","nonce":"00"}`)
+	assert.Error(t, err, "short nonce")
+	_, err = ParseChallenge("not a challenge")
+	assert.Error(t, err)
+}
+
+func TestLogin(t *testing.T) {
+	v := NewVerifier("https://app.example")
+	srv := httptest.NewServer(v.Handler())
+	defer srv.Close()
+
+	acc := newAccount(t, "ed25519")
+	addr := accounts.StringAddress(acc.Address())
+
+	c := getChallenge(t, srv.URL+"/challenge?address="+addr)
+	assert.Equal(t, "https://app.example", c.Origin)
+	assert.Equal(t, addr, c.Address)
+
+	// the challenge goes through the user's clipboard
+	c, err := ParseChallenge(c.Encode())
+	assert.NoError(t, err)
+	r, err := Respond(c, acc, sign)
+	assert.NoError(t, err)
+
+	body, err := json.Marshal(r)
+	assert.NoError(t, err)
+	resp, err := http.Post(srv.URL+"/login", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	result := map[string]string{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, acc.Address().String(), result["address"])
+
+	assert.Equal(t, http.StatusUnauthorized, login(t, srv.URL, r), "replayed response")
+}
+
+func TestLoginRejected(t *testing.T) {
+	v := NewVerifier("https://app.example")
+	srv := httptest.NewServer(v.Handler())
+	defer srv.Close()
+
+	acc := newAccount(t, "ed25519")
+	other := newAccount(t, "ed25519")
+
+	// signed by another account than the requested one
+	c := getChallenge(t, srv.URL+"/challenge?address="+accounts.StringAddress(acc.Address()))
+	_, err := Respond(c, other, sign)
+	assert.Error(t, err)
+	forged := *c
+	forged.Address = ""
+	r, err := Respond(&forged, other, sign)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, login(t, srv.URL, r))
+
+	// tampered signature
+	c = getChallenge(t, srv.URL+"/challenge")
+	r, err = Respond(c, acc, sign)
+	assert.NoError(t, err)
+	sig, err := hex.DecodeString(r.Signature)
+	assert.NoError(t, err)
+	sig[0] ^= 1
+	r.Signature = hex.EncodeToString(sig)
+	assert.Equal(t, http.StatusUnauthorized, login(t, srv.URL, r))
+
+	// challenge not issued by the server
+	c, err = NewChallenge("https://app.example", "", time.Minute)
+	assert.NoError(t, err)
+	r, err = Respond(c, acc, sign)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, login(t, srv.URL, r))
+
+	// expired challenge
+	c = getChallenge(t, srv.URL+"/challenge")
+	r, err = Respond(c, acc, sign)
+	assert.NoError(t, err)
+	v.now = func() time.Time { return time.Now().Add(DefaultTTL + time.Second) }
+	assert.Equal(t, http.StatusUnauthorized, login(t, srv.URL, r))
+	v.now = time.Now
+
+	// response with a changed challenge copy is checked against the issued challenge
+	c = getChallenge(t, srv.URL+"/challenge")
+	c.Origin = "https://evil.example"
+	r, err = Respond(c, acc, sign)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, login(t, srv.URL, r))
+
+	_, err = Respond(c, newAccount(t, "secp256k1"), sign)
+	assert.Error(t, err, "ed25519 accounts only")
+}
+
+func TestVerifyExpired(t *testing.T) {
+	v := NewVerifier("https://app.example")
+	acc := newAccount(t, "ed25519")
+
+	c, err := v.Challenge("")
+	assert.NoError(t, err)
+	r, err := Respond(c, acc, sign)
+	assert.NoError(t, err)
+
+	v.now = func() time.Time { return c.Expires }
+	_, err = v.Verify(r)
+	assert.Equal(t, ErrExpired, err)
+
+	c.Expires = time.Now().Add(-time.Second)
+	_, err = Respond(c, acc, sign)
+	assert.Error(t, err)
+}
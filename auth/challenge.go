@@ -0,0 +1,140 @@
+// Package auth lets users prove control of a libonomy address to a web service. The service issues a challenge
+// naming its origin, a random nonce, an expiry time and optionally the address it expects; the wallet signs the
+// challenge with the ed25519 account key and the service verifies the response once.
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+const nonceSize = 32
+
+// Challenge is a login challenge issued by the service at Origin.
+type Challenge struct {
+	Origin  string    `json:"origin"`
+	Nonce   string    `json:"nonce"` // hex encoded
+	Expires time.Time `json:"expires"`
+	Address string    `json:"address,omitempty"` // address requested to sign, any address if empty
+}
+
+// Response is a challenge signed by the account with address Address.
+type Response struct {
+	Challenge Challenge `json:"challenge"`
+	Address   string    `json:"address"`
+	PubKey    string    `json:"pubkey"`
+	Signature string    `json:"signature"`
+}
+
+// NewChallenge returns a challenge of origin with a random nonce, expiring after ttl.
+func NewChallenge(origin, addr string, ttl time.Duration) (*Challenge, error) {
+	if origin == "" {
+		return nil, fmt.Errorf("missing origin")
+	}
+	if addr != "" && !address.IsHexAddress(addr) {
+		return nil, fmt.Errorf("invalid address %s", addr)
+	}
+	nonce, err := crypto.GetRandomBytes(nonceSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{Origin: origin, Nonce: hex.EncodeToString(nonce), Expires: time.Now().Add(ttl).UTC().Round(time.Second), Address: addr}, nil
+}
+
+// Message returns the text signed to answer the challenge. It is readable so users can check what they sign.
+func (c *Challenge) Message() []byte {
+	addr := c.Address
+	if addr == "" {
+		addr = "any"
+	}
+	return []byte(fmt.Sprintf("libonomy login\norigin: %s\nnonce: %s\nexpires: %s\naddress: %s",
+		c.Origin, strings.ToLower(c.Nonce), c.Expires.UTC().Format(time.RFC3339), strings.ToLower(addr)))
+}
+
+// Expired reports whether the challenge expired at now.
+func (c *Challenge) Expired(now time.Time) bool {
+	return !now.Before(c.Expires)
+}
+
+// Encode returns the challenge as URL safe base64 JSON, to be copied into the wallet.
+func (c *Challenge) Encode() string {
+	return encode(c)
+}
+
+// ParseChallenge decodes a challenge given as JSON or as encoded by Encode.
+func ParseChallenge(s string) (*Challenge, error) {
+	c := &Challenge{}
+	if err := decode(s, c); err != nil {
+		return nil, err
+	}
+	if c.Origin == "" {
+		return nil, fmt.Errorf("missing origin")
+	}
+	if nonce, err := hex.DecodeString(c.Nonce); err != nil || len(nonce) != nonceSize {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	if c.Address != "" && !address.IsHexAddress(c.Address) {
+		return nil, fmt.Errorf("invalid address %s", c.Address)
+	}
+	return c, nil
+}
+
+// Respond signs the challenge as the ed25519 account acc with sign, e.g. WalletBE.Sign.
+func Respond(c *Challenge, acc *accounts.Account, sign func(*accounts.Account, []byte) ([]byte, error)) (*Response, error) {
+	if acc.Scheme.Name() != "ed25519" {
+		return nil, fmt.Errorf("login requires an ed25519 account, `%s` uses %s", acc.Name, acc.Scheme.Name())
+	}
+	addr := acc.Address()
+	if c.Address != "" && address.HexToAddress(c.Address) != addr {
+		return nil, fmt.Errorf("challenge requests address %s, account `%s` has address %s", c.Address, acc.Name, accounts.StringAddress(addr))
+	}
+	if c.Expired(time.Now()) {
+		return nil, fmt.Errorf("challenge expired at %s", c.Expires.Format(time.RFC3339))
+	}
+
+	// the ed25519 scheme signs with ed25519.Sign2
+	sig, err := sign(acc, c.Message())
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Challenge: *c, Address: accounts.StringAddress(addr), PubKey: hex.EncodeToString(acc.PubKey), Signature: hex.EncodeToString(sig)}, nil
+}
+
+// Encode returns the response as URL safe base64 JSON, to be pasted into the service.
+func (r *Response) Encode() string {
+	return encode(r)
+}
+
+// ParseResponse decodes a response given as JSON or as encoded by Encode.
+func ParseResponse(s string) (*Response, error) {
+	r := &Response{}
+	if err := decode(s, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(s string, v interface{}) error {
+	s = strings.TrimSpace(s)
+	data := []byte(s)
+	if !strings.HasPrefix(s, "{") {
+		var err error
+		if data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "=")); err != nil {
+			return fmt.Errorf("expected JSON or base64: %v", err)
+		}
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libonomy/ed25519"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// DefaultTTL is the lifetime of challenges issued by a Verifier.
+const DefaultTTL = 5 * time.Minute
+
+// Verification errors.
+var (
+	ErrUnknownChallenge = errors.New("unknown or already used challenge")
+	ErrExpired          = errors.New("challenge expired")
+	ErrBadSignature     = errors.New("invalid signature")
+)
+
+// Verifier issues challenges for a service and verifies the responses. Each challenge is accepted at most once.
+type Verifier struct {
+	Origin string
+	TTL    time.Duration
+
+	now     func() time.Time
+	mu      sync.Mutex
+	pending map[string]*Challenge // issued and unanswered challenges by nonce
+}
+
+// NewVerifier returns a verifier issuing challenges of origin valid for DefaultTTL.
+func NewVerifier(origin string) *Verifier {
+	return &Verifier{Origin: origin, TTL: DefaultTTL, now: time.Now, pending: make(map[string]*Challenge)}
+}
+
+// Challenge issues a new challenge to be signed by addr, or by any address if addr is empty.
+func (v *Verifier) Challenge(addr string) (*Challenge, error) {
+	c, err := NewChallenge(v.Origin, addr, v.TTL)
+	if err != nil {
+		return nil, err
+	}
+	c.Expires = v.now().Add(v.TTL).UTC().Round(time.Second)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.prune()
+	v.pending[c.Nonce] = c
+	return c, nil
+}
+
+// prune drops expired challenges, called with mu held.
+func (v *Verifier) prune() {
+	now := v.now()
+	for nonce, c := range v.pending {
+		if c.Expired(now) {
+			delete(v.pending, nonce)
+		}
+	}
+}
+
+// Verify checks that r answers a challenge issued by v, before its expiry and with a valid signature of the
+// requested address. It returns the proven address. The challenge is consumed whatever the outcome so a
+// response can't be replayed or retried.
+func (v *Verifier) Verify(r *Response) (address.Address, error) {
+	v.mu.Lock()
+	issued, ok := v.pending[strings.ToLower(r.Challenge.Nonce)]
+	delete(v.pending, strings.ToLower(r.Challenge.Nonce))
+	v.mu.Unlock()
+
+	// check against the issued challenge, never the copy sent back by the client
+	if !ok {
+		return address.Address{}, ErrUnknownChallenge
+	}
+	if issued.Expired(v.now()) {
+		return address.Address{}, ErrExpired
+	}
+
+	pub, err := hex.DecodeString(r.PubKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return address.Address{}, fmt.Errorf("invalid public key")
+	}
+	addr := address.BytesToAddress(pub)
+	if !address.IsHexAddress(r.Address) || address.HexToAddress(r.Address) != addr {
+		return address.Address{}, fmt.Errorf("address %s does not match the public key", r.Address)
+	}
+	if issued.Address != "" && address.HexToAddress(issued.Address) != addr {
+		return address.Address{}, fmt.Errorf("challenge requests address %s", issued.Address)
+	}
+
+	sig, err := hex.DecodeString(r.Signature)
+	if err != nil || !ed25519.Verify2(pub, issued.Message(), sig) {
+		return address.Address{}, ErrBadSignature
+	}
+	return addr, nil
+}
+
+// Handler serves the challenge and login endpoints of v:
+//
+//	GET  /challenge[?address=0x..]  returns a new challenge as JSON
+//	POST /login                     verifies the JSON response in the body and returns the proven address
+func (v *Verifier) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/challenge", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		c, err := v.Challenge(req.URL.Query().Get("address"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, c)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r := &Response{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, 1<<16)).Decode(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		addr, err := v.Verify(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]string{"address": addr.String()})
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// approvalsSection is the wallet section holding the approval policies by lowercase account address.
+const approvalsSection = "approvals"
+
+// Approved is a transaction collecting approvals, see approval.Envelope.
+type Approved interface {
+	Sender() (address.Address, error)
+	Network() int8
+	Transaction() InnerSerializableSignedTransaction
+	// CheckPolicy checks the approvals against a JSON policy and returns the names of the approvers.
+	CheckPolicy(policy []byte) ([]string, error)
+}
+
+// approvalPolicies returns the approval policies stored in the open wallet.
+func (w *WalletBE) approvalPolicies() (map[string]json.RawMessage, error) {
+	policies := make(map[string]json.RawMessage)
+	if _, err := w.meta.Section(approvalsSection, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// ApprovalPolicy returns the JSON approval policy of account name, or nil if its transfers need no approvals.
+func (w *WalletBE) ApprovalPolicy(name string) ([]byte, error) {
+	addr, err := w.Store.AccountAddress(name)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := w.approvalPolicies()
+	if err != nil {
+		return nil, err
+	}
+	return policies[strings.ToLower(accounts.StringAddress(addr))], nil
+}
+
+// SetApprovalPolicy stores the JSON approval policy of account name in the wallet, a nil policy removes it. Once an
+// account has a policy, its transfers are only sent by TransferApproved. Changing or removing a policy requires the
+// account passphrase and is recorded in the audit log.
+func (w *WalletBE) SetApprovalPolicy(name string, policy []byte, passphrase []byte) error {
+	addr, err := w.Store.AccountAddress(name)
+	if err != nil {
+		return err
+	}
+	policies, err := w.approvalPolicies()
+	if err != nil {
+		return err
+	}
+	key := strings.ToLower(accounts.StringAddress(addr))
+	_, exists := policies[key]
+	if exists {
+		if err := w.VerifyPassphrase(name, passphrase); err != nil {
+			return err
+		}
+	}
+	if policy == nil && !exists {
+		return nil
+	}
+
+	details := map[string]string{"alias": name}
+	if policy == nil {
+		delete(policies, key)
+		details["policy"] = "none"
+	} else {
+		policies[key] = policy
+		details["policy"] = string(policy)
+	}
+	if len(policies) == 0 {
+		err = w.meta.SetSection(approvalsSection, nil)
+	} else {
+		err = w.meta.SetSection(approvalsSection, policies)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.record("approval-policy", key, details); err != nil {
+		return fmt.Errorf("cannot write audit log: %v", err)
+	}
+	return w.StoreAccounts()
+}
+
+// TransferApproved signs the transaction of a with the key of its sender from and submits it to the node, once it
+// is approved as required by the approval policy of from stored in the wallet.
+func (w *WalletBE) TransferApproved(a Approved, from *accounts.Account) (string, error) {
+	sender, err := a.Sender()
+	if err != nil {
+		return "", err
+	}
+	if sender != from.Address() {
+		return "", fmt.Errorf("the transaction is sent by %s, not by `%s`", accounts.StringAddress(sender), from.Name)
+	}
+	if a.Network() != w.meta.NetworkID {
+		return "", fmt.Errorf("the transaction is for network %d but the wallet uses network %d", a.Network(), w.meta.NetworkID)
+	}
+	policy, err := w.ApprovalPolicy(from.Name)
+	if err != nil {
+		return "", err
+	}
+	if policy == nil {
+		return "", fmt.Errorf("account `%s` has no approval policy", from.Name)
+	}
+	approvers, err := a.CheckPolicy(policy)
+	if err != nil {
+		return "", fmt.Errorf("the transaction is not approved: %v", err)
+	}
+
+	tx := a.Transaction()
+	id, err := w.transfer(tx.Recipient, tx.AccountNonce, tx.Amount, tx.Price, tx.GasLimit, from)
+	if err != nil {
+		return "", err
+	}
+	w.Audit("approval-broadcast", from, map[string]string{"approvers": strings.Join(approvers, ", "), "txId": id})
+	return id, nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+	"github.com/stretchr/testify/assert"
+)
+
+// approved is a transaction whose approvals are accepted if ok is set.
+type approved struct {
+	from    address.Address
+	network int8
+	tx      InnerSerializableSignedTransaction
+	ok      bool
+	policy  []byte // policy the approvals were checked against
+}
+
+func (a *approved) Sender() (address.Address, error)                { return a.from, nil }
+func (a *approved) Network() int8                                   { return a.network }
+func (a *approved) Transaction() InnerSerializableSignedTransaction { return a.tx }
+
+func (a *approved) CheckPolicy(policy []byte) ([]string, error) {
+	a.policy = policy
+	if !a.ok {
+		return nil, errors.New("0 of 1 required approvals")
+	}
+	return []string{"bob"}, nil
+}
+
+func TestApprovalPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(p crypto.KDParams) { crypto.DefaultCypherParams = p }(crypto.DefaultCypherParams)
+	crypto.DefaultCypherParams.N = 1024
+
+	be, err := NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	be.SetNewWalletPassphrase(walletPassphrase)
+	acc, err := be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+	bob := address.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	policy := []byte(`{"threshold":1}`)
+	assert.NoError(t, be.SetApprovalPolicy("alice", policy, nil), "first policy")
+	_, err = be.Transfer(bob, 0, 1, 1, 100, acc)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "require approvals")
+
+	// the policy is kept in the wallet file
+	be, err = NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	stored, err := be.ApprovalPolicy("alice")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(policy), string(stored))
+
+	a := &approved{from: acc.Address(), network: be.WalletMetadata().NetworkID + 1}
+	_, err = be.TransferApproved(a, acc)
+	assert.Error(t, err, "wrong network")
+	a.network = be.WalletMetadata().NetworkID
+	_, err = be.TransferApproved(a, acc)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not approved")
+	assert.JSONEq(t, string(policy), string(a.policy), "approvals are checked against the stored policy")
+
+	assert.Error(t, be.SetApprovalPolicy("alice", nil, []byte("wrong")))
+	assert.NoError(t, be.SetApprovalPolicy("alice", nil, []byte("beagles")))
+	stored, err = be.ApprovalPolicy("alice")
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+	_, err = be.TransferApproved(a, acc)
+	assert.Error(t, err, "no policy")
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/audit"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/log"
+)
+
+// auditSection is the wallet section holding the audit log state.
+const auditSection = "audit"
+
+// auditState is the audit log state kept in the wallet file, authenticated with the accounts.
+type auditState struct {
+	Head    *audit.Head    `json:"head,omitempty"`    // last checkpoint signed with the wallet open
+	Signers []audit.Signer `json:"signers,omitempty"` // keys that signed checkpoints, kept when accounts are deleted
+}
+
+// auditFileName returns the name of the audit log file of wallet name in the logs directory.
+func auditFileName(name string) string {
+	if name == DefaultWalletName {
+		return audit.FileName
+	}
+	return "audit-" + name + ".log"
+}
+
+// Audit records event concerning account acc, which may be nil, in the audit log. Failures are logged.
+func (w *WalletBE) Audit(event string, acc *accounts.Account, details map[string]string) {
+	if acc != nil {
+		if details == nil {
+			details = make(map[string]string)
+		}
+		details["alias"] = acc.Name
+		if err := w.record(event, accounts.StringAddress(acc.Address()), details); err != nil {
+			log.Error("cannot write audit log: %v", err)
+		}
+		return
+	}
+	if err := w.record(event, "", details); err != nil {
+		log.Error("cannot write audit log: %v", err)
+	}
+}
+
+// messageDetails describes msg in the audit log by its SHA3-256 digest and length, never by its contents.
+func messageDetails(msg []byte) map[string]string {
+	return map[string]string{"digest": hex.EncodeToString(crypto.Sha256(msg)), "length": strconv.Itoa(len(msg))}
+}
+
+// auditAccount records event concerning the account name, ignoring unknown accounts.
+func (w *WalletBE) auditAccount(event, name string, details map[string]string) {
+	acc, err := w.Store.GetAccount(name)
+	if err != nil {
+		return
+	}
+	w.Audit(event, acc, details)
+}
+
+// record appends event to the audit log and signs a checkpoint when one is due.
+func (w *WalletBE) record(event, account string, details map[string]string) error {
+	if err := w.audit.Append(event, account, details); err != nil {
+		return err
+	}
+	w.checkpoint()
+	return nil
+}
+
+// checkpoint signs the audit log with the current account once audit.CheckpointInterval entries are unsigned. It
+// does nothing if signing would require a passphrase or a token PIN.
+func (w *WalletBE) checkpoint() {
+	acc := w.currentAccount
+	if acc == nil || (acc.IsLocked() && w.signer == nil) {
+		return
+	}
+	if _, ok := w.Store.HSMKey(acc.Name); ok {
+		return
+	}
+	if n, err := w.audit.Unsigned(); err != nil || n < audit.CheckpointInterval {
+		return
+	}
+	if err := w.AuditCheckpoint(acc); err != nil {
+		log.Error("cannot sign audit log checkpoint: %v", err)
+	}
+}
+
+// AuditCheckpoint signs the audit log entries so far with the key of account acc. The checkpoint and the key are
+// recorded in the wallet file, see VerifyAudit.
+func (w *WalletBE) AuditCheckpoint(acc *accounts.Account) error {
+	signer := audit.Signer{Scheme: acc.Scheme.Name(), PubKey: acc.Scheme.EncodeKey(acc.PubKey)}
+	// the checkpoint entry records the signature, and Sign would append to the log while it is being signed
+	e, err := w.audit.Checkpoint(accounts.StringAddress(acc.Address()), signer.Scheme, signer.PubKey,
+		func(msg []byte) ([]byte, error) { return w.sign(acc, msg) })
+	if err != nil || e == nil {
+		return err
+	}
+
+	state, err := w.auditState()
+	if err != nil {
+		return err
+	}
+	state.Head = &audit.Head{Seq: e.Seq, Hash: e.Hash}
+	known := false
+	for _, s := range state.Signers {
+		known = known || s == signer
+	}
+	if !known {
+		state.Signers = append(state.Signers, signer)
+	}
+	if err := w.meta.SetSection(auditSection, state); err != nil {
+		return err
+	}
+	// wallets without a wallet passphrase keep the checkpoint until they are next stored
+	if !w.meta.IsProtected() {
+		return nil
+	}
+	if err := w.StoreAccounts(); err != nil {
+		return fmt.Errorf("cannot record the checkpoint in the wallet: %v", err)
+	}
+	return nil
+}
+
+// auditState returns the audit log state recorded in the open wallet.
+func (w *WalletBE) auditState() (*auditState, error) {
+	state := &auditState{}
+	if _, err := w.meta.Section(auditSection, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// VerifyAudit checks the audit log for deleted or modified entries, see audit.Verify. Checkpoints must be signed by
+// an account of the wallet or a key that signed a checkpoint recorded in the wallet, and the last recorded
+// checkpoint must still be in the log.
+func (w *WalletBE) VerifyAudit() (*audit.Report, error) {
+	state, err := w.auditState()
+	if err != nil {
+		return nil, err
+	}
+	signers := state.Signers
+	for name := range w.Store {
+		if acc, err := w.Store.GetAccount(name); err == nil {
+			signers = append(signers, audit.Signer{Scheme: acc.Scheme.Name(), PubKey: acc.Scheme.EncodeKey(acc.PubKey)})
+		}
+	}
+	return audit.Verify(w.audit.Path(), signers, state.Head)
+}
+
+// AuditLogPath returns the path of the audit log file.
+func (w *WalletBE) AuditLogPath() string {
+	return w.audit.Path()
+}
+
+// RenameAccount changes the alias of account name and records it in the audit log. Callers store the accounts.
+func (w *WalletBE) RenameAccount(name, newName string) error {
+	if err := w.Store.RenameAccount(name, newName); err != nil {
+		return err
+	}
+	w.auditAccount("account-rename", newName, map[string]string{"previous": name})
+	return nil
+}
+
+// SetArchived hides or restores account name and records it in the audit log. Callers store the accounts.
+func (w *WalletBE) SetArchived(name string, archived bool) error {
+	if err := w.Store.SetArchived(name, archived); err != nil {
+		return err
+	}
+	w.auditAccount("account-archive", name, map[string]string{"archived": strconv.FormatBool(archived)})
+	return nil
+}
+
+// ChangePassphrase re-encrypts the private key of account name with newPassphrase and records it in the audit log.
+// Callers store the accounts.
+func (w *WalletBE) ChangePassphrase(name string, passphrase, newPassphrase []byte) error {
+	if err := w.Store.CheckNetwork(name, w.meta.NetworkID); err != nil {
+		return err
+	}
+	if err := w.Store.ChangePassphrase(name, passphrase, newPassphrase, w.kdf); err != nil {
+		return err
+	}
+	w.auditAccount("account-passphrase", name, nil)
+	return nil
+}
+
+// GenerateVRFKey generates a VRF key for account name, see accounts.Store.GenerateVRFKey, and records it in the
+// audit log.
+func (w *WalletBE) GenerateVRFKey(name string, passphrase []byte) ([]byte, error) {
+	if err := w.Store.CheckNetwork(name, w.meta.NetworkID); err != nil {
+		return nil, err
+	}
+	pub, err := w.Store.GenerateVRFKey(name, passphrase, w.kdf)
+	if err != nil {
+		return nil, err
+	}
+	w.auditAccount("account-vrf-key", name, map[string]string{"vrfPubKey": hex.EncodeToString(pub)})
+	return pub, nil
+}
+
+// ExportKeystore returns the keystore JSON of account name and records the export in the audit log.
+func (w *WalletBE) ExportKeystore(name string) ([]byte, error) {
+	keystore, err := w.Store.ExportKeystore(name)
+	if err != nil {
+		return nil, err
+	}
+	w.auditAccount("export", name, map[string]string{"format": "keystore"})
+	return keystore, nil
+}
+
+// describeTransfer returns the audit details of a transfer.
+func describeTransfer(tx *SerializableSignedTransaction, id string) map[string]string {
+	return map[string]string{
+		"recipient": accounts.StringAddress(tx.Recipient),
+		"amount":    strconv.FormatUint(tx.Amount, 10),
+		"gasPrice":  strconv.FormatUint(tx.Price, 10),
+		"gasLimit":  strconv.FormatUint(tx.GasLimit, 10),
+		"nonce":     strconv.FormatUint(tx.AccountNonce, 10),
+		"txId":      id,
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/audit"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletBEAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(p crypto.KDParams) { crypto.DefaultCypherParams = p }(crypto.DefaultCypherParams)
+	crypto.DefaultCypherParams.N = 1024
+
+	be, err := NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	be.SetNewWalletPassphrase(walletPassphrase)
+	acc, err := be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+	assert.NoError(t, be.RenameAccount("alice", "alicia"))
+	assert.NoError(t, be.ChangePassphrase("alicia", []byte("beagles"), []byte("poodles")))
+	_, err = be.ExportKeystore("alicia")
+	assert.NoError(t, err)
+	assert.Error(t, be.SetArchived("bob", true), "failures are not recorded")
+
+	// no checkpoint without an unlocked current account
+	for i := 0; i < audit.CheckpointInterval; i++ {
+		be.Audit("sign", acc, nil)
+	}
+	report, err := be.VerifyAudit()
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 4+audit.CheckpointInterval, report.Entries)
+	assert.Empty(t, report.Checkpoints)
+
+	// the unlocked current account signs a checkpoint when one is due
+	be.SetCurrentAccount(acc)
+	be.Audit("textsign", acc, nil)
+	report, err = be.VerifyAudit()
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Len(t, report.Checkpoints, 1)
+	assert.Equal(t, 0, report.Unsigned)
+	assert.NoError(t, be.StoreAccounts())
+
+	// the wallet records the checkpoint and its signer
+	be, err = NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	report, err = be.VerifyAudit()
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.NoError(t, be.Store.DeleteAccount("alicia"))
+	report, err = be.VerifyAudit()
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "deleted accounts stay trusted: %v", report.Problems)
+
+	// a log signed with a key unknown to the wallet does not verify
+	other, err := accounts.Store{}.CreateAccount("mallory", "ed25519", 0, []byte("beagles"), crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	be.Audit("sign", nil, nil)
+	_, err = audit.OpenFile(be.AuditLogPath()).Checkpoint(accounts.StringAddress(other.Address()), other.Scheme.Name(),
+		other.Scheme.EncodeKey(other.PubKey), other.Sign)
+	assert.NoError(t, err)
+	report, err = be.VerifyAudit()
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+
+	// deleting the log is detected
+	assert.NoError(t, os.Remove(be.AuditLogPath()))
+	report, err = be.VerifyAudit()
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+}
+
+func TestWalletBESignAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(p crypto.KDParams) { crypto.DefaultCypherParams = p }(crypto.DefaultCypherParams)
+	crypto.DefaultCypherParams.N = 1024
+
+	be, err := NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	be.SetNewWalletPassphrase(walletPassphrase)
+	acc, err := be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+
+	msg := []byte("hello")
+	_, err = be.Sign(acc, msg)
+	assert.NoError(t, err)
+	ciphertext, err := acc.Scheme.Encrypt(acc.PubKey, msg)
+	assert.NoError(t, err)
+	_, err = be.Decrypt(acc, ciphertext)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(be.AuditLogPath())
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	entries := make([]audit.Entry, len(lines))
+	for i, line := range lines {
+		assert.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+	}
+	assert.Len(t, entries, 3)
+	sign := entries[1]
+	assert.Equal(t, "sign", sign.Event)
+	assert.Equal(t, accounts.StringAddress(acc.Address()), sign.Account)
+	assert.Equal(t, hex.EncodeToString(crypto.Sha256(msg)), sign.Details["digest"], "only the digest is recorded")
+	assert.Equal(t, entries[0].Hash, sign.Prev)
+	assert.Equal(t, "decrypt", entries[2].Event)
+	assert.Equal(t, sign.Hash, entries[2].Prev)
+
+	report, err := be.VerifyAudit()
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+}
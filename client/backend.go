@@ -2,45 +2,412 @@ package client
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"path"
+	"strings"
 
 	xdr "github.com/davecgh/go-xdr/xdr2"
-	"github.com/libonomy/ed25519"
 	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/audit"
+	"github.com/libonomy/wallet-cli/contacts"
+	"github.com/libonomy/wallet-cli/hsm"
+	"github.com/libonomy/wallet-cli/os/app/config"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/filesystem"
 	"github.com/libonomy/wallet-cli/os/log"
+	"github.com/libonomy/wallet-cli/paper"
+	"github.com/libonomy/wallet-cli/signer"
+	"github.com/libonomy/wallet-cli/spending"
 	"github.com/libonomy/wallet-cli/wallet/address"
 )
 
-const accountsFileName = "accounts.json"
+const (
+	accountsFileName = "accounts.json"
+	contactsFileName = "contacts.json"
+	tombstonesDir    = "tombstones"
+)
+
+// ErrNoWallet is returned by operations that need an open wallet after the wallet was closed.
+var ErrNoWallet = errors.New("no wallet is open, use `wallet open`")
 
 type WalletBE struct {
 	*HTTPRequester
 	accounts.Store
+	contacts.Book
+	meta             *accounts.Metadata
+	wallets          *Wallets
+	kdf              crypto.KDParams // key derivation params of new keys and wallet passphrases
+	walletName       string
 	accountsFilePath string
+	contactsFilePath string
+	walletsFilePath  string
+	datadir          string
+	server           string // node host:port overriding the wallet settings, if not empty
+	passphrase       func() []byte
+	newPassphrase    func() []byte // asked for the passphrase of wallets stored for the first time
+	signer           signer.Signer // signs instead of the unlocked accounts if set
+	hsm              *hsm.Signer   // signs for the accounts kept in PKCS#11 tokens
+	tokenPIN         func(token string) []byte
 	currentAccount   *accounts.Account
+	balances         map[string]string // last known balance by hex address, see cacheBalance
+	balancesFilePath string
+	fees             *feeCache
+	spending         *spending.Ledger
+	override         *spending.Transfer // transfer allowed once despite the spending policy, see OverrideSpending
+	audit            *audit.Log         // audit log of the open wallet
+	logsDir          string
+}
+
+// NewWalletBE opens the wallet called walletName in datadir, or the default wallet if walletName is empty. If
+// serverHostPort is not empty it is used instead of the node configured for each wallet. passphrase is called to get
+// the wallet passphrase if the wallet contents are protected. A wallet failing its integrity check is opened
+// read-only, see WalletMetadata.
+func NewWalletBE(serverHostPort, datadir, walletName string, passphrase func() []byte) (*WalletBE, error) {
+	walletsFilePath := path.Join(datadir, walletsFileName)
+	wallets, err := LoadWallets(walletsFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load wallet settings from file %s: %v", walletsFilePath, err)
+	}
+
+	contactsFilePath := path.Join(datadir, contactsFileName)
+	book, err := contacts.LoadBook(contactsFilePath)
+	if err != nil {
+		log.Error("cannot load address book from file %s: %s", contactsFilePath, err)
+		book = &contacts.Book{}
+	}
+
+	// the logs directory is in the wallet data directory
+	config.ConfigValues.DataFilePath = datadir
+	logsDir, err := filesystem.GetLogsDataDirectoryPath()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create logs directory: %v", err)
+	}
+
+	kdf := crypto.DefaultCypherParams
+	if wallets.KDF != nil {
+		kdf = *wallets.KDF
+	}
+
+	w := &WalletBE{
+		Store:            accounts.Store{},
+		Book:             *book,
+		meta:             accounts.NewMetadata(),
+		wallets:          wallets,
+		kdf:              kdf,
+		contactsFilePath: contactsFilePath,
+		walletsFilePath:  walletsFilePath,
+		datadir:          datadir,
+		server:           serverHostPort,
+		passphrase:       passphrase,
+		balances:         loadBalances(path.Join(datadir, balancesFileName)),
+		balancesFilePath: path.Join(datadir, balancesFileName),
+		spending:         spending.NewLedger(),
+		logsDir:          logsDir,
+		audit:            audit.Open(logsDir),
+	}
+	w.hsm = hsm.NewSigner(w.askTokenPIN)
+
+	if walletName == "" {
+		walletName = wallets.Default
+	}
+	if err := w.OpenWallet(walletName); err != nil && err != accounts.ErrTampered {
+		return nil, err
+	}
+	return w, nil
+}
+
+// OpenWallet loads the wallet called name and connects to its node. The previously open wallet is closed. Like
+// accounts.LoadAccounts it returns accounts.ErrTampered with the wallet opened read-only.
+func (w *WalletBE) OpenWallet(name string) error {
+	settings, err := w.wallets.Get(name)
+	if err != nil {
+		return err
+	}
+
+	accountsFilePath := path.Join(w.datadir, settings.File)
+	acc, meta, err := accounts.LoadAccounts(accountsFilePath, w.passphrase)
+	if os.IsNotExist(err) {
+		acc, meta, err = &accounts.Store{}, accounts.NewMetadata(), nil
+	} else if err != nil && err != accounts.ErrTampered {
+		// never fall back to an empty store here, storing it would overwrite the wallet file
+		return fmt.Errorf("cannot load accounts from file %s: %v", accountsFilePath, err)
+	} else if err == nil && settings.Protected && !meta.IsProtected() {
+		// a protected wallet file rewritten without its tag, in an older format or not, must not load as legacy
+		log.Error("%v. file=%v", accounts.ErrTampered, accountsFilePath)
+		meta.MarkTampered()
+		err = accounts.ErrTampered
+	} else if err == nil && meta.IsProtected() {
+		if err := w.markProtected(name); err != nil {
+			return fmt.Errorf("cannot store wallet settings: %v", err)
+		}
+	}
+
+	// spending caps must not be lifted by an unreadable ledger
+	ledger := spending.NewLedger()
+	if _, err := meta.Section(spendingSection, ledger); err != nil {
+		return fmt.Errorf("cannot load spending ledger from file %s: %v", accountsFilePath, err)
+	}
+
+	w.CloseWallet()
+	w.Store, w.meta, w.spending = *acc, meta, ledger
+	w.walletName, w.accountsFilePath = name, accountsFilePath
+	w.audit = audit.OpenFile(path.Join(w.logsDir, auditFileName(name)))
+	w.connect()
+	return err
 }
 
-func NewWalletBE(serverHostPort, datadir string) (*WalletBE, error) {
-	accountsFilePath := path.Join(datadir, accountsFileName)
-	acc, err := accounts.LoadAccounts(accountsFilePath)
+// CloseWallet locks the current account, logs out of the tokens and unloads the open wallet.
+func (w *WalletBE) CloseWallet() {
+	w.SetCurrentAccount(nil)
+	w.hsm.Close()
+	w.Store, w.meta, w.spending = accounts.Store{}, accounts.NewMetadata(), spending.NewLedger()
+	w.walletName, w.accountsFilePath = "", ""
+}
+
+// connect points the node client at the node of the open wallet.
+func (w *WalletBE) connect() {
+	server := w.server
+	if server == "" {
+		if settings, err := w.wallets.Get(w.walletName); err == nil {
+			server = settings.Server
+		}
+	}
+	if server == "" {
+		server = DefaultNodeHostPort
+	}
+	w.HTTPRequester = NewHTTPRequester(fmt.Sprintf("http://%s/v1", server))
+}
+
+// CreateWallet creates an empty wallet called name, protected by passphrase. It does not open it.
+func (w *WalletBE) CreateWallet(name string, passphrase []byte) error {
+	settings, err := w.wallets.Add(name)
 	if err != nil {
-		log.Error("cannot load account from file %s: %s", accountsFilePath, err)
-		acc = &accounts.Store{}
+		return err
+	}
+
+	filePath := path.Join(w.datadir, settings.File)
+	if _, err := os.Stat(filePath); err == nil {
+		delete(w.wallets.Wallets, name)
+		return fmt.Errorf("wallet file %s already exists", filePath)
+	}
+	if err := os.MkdirAll(path.Dir(filePath), 0700); err != nil {
+		delete(w.wallets.Wallets, name)
+		return err
+	}
+	meta := accounts.NewMetadata()
+	if err := meta.SetPassphrase(passphrase, w.kdf); err != nil {
+		delete(w.wallets.Wallets, name)
+		return err
 	}
+	if err := accounts.StoreAccounts(filePath, &accounts.Store{}, meta); err != nil {
+		delete(w.wallets.Wallets, name)
+		return err
+	}
+	settings.Protected = true
+	return StoreWallets(w.walletsFilePath, w.wallets)
+}
+
+// markProtected records in the settings of wallet name that its file is protected by a wallet passphrase. From then
+// on a file of that wallet without an integrity tag is treated as tampered.
+func (w *WalletBE) markProtected(name string) error {
+	settings, err := w.wallets.Get(name)
+	if err != nil || settings.Protected {
+		return err
+	}
+	settings.Protected = true
+	return StoreWallets(w.walletsFilePath, w.wallets)
+}
+
+// WalletName returns the name of the open wallet, or an empty string if it was closed.
+func (w *WalletBE) WalletName() string {
+	return w.walletName
+}
 
-	url := fmt.Sprintf("http://%s/v1", serverHostPort)
-	return &WalletBE{NewHTTPRequester(url), *acc, accountsFilePath, nil}, nil
+// ListWallets returns the sorted names of the wallets in the data directory.
+func (w *WalletBE) ListWallets() []string {
+	return w.wallets.List()
+}
+
+// DefaultWallet returns the name of the wallet opened at start.
+func (w *WalletBE) DefaultWallet() string {
+	return w.wallets.Default
+}
+
+// SetDefaultWallet sets wallet name as the one opened at start.
+func (w *WalletBE) SetDefaultWallet(name string) error {
+	if err := w.wallets.SetDefault(name); err != nil {
+		return err
+	}
+	return StoreWallets(w.walletsFilePath, w.wallets)
+}
+
+// WalletServer returns the node host:port configured for the open wallet, empty for DefaultNodeHostPort.
+func (w *WalletBE) WalletServer() string {
+	if settings, err := w.wallets.Get(w.walletName); err == nil {
+		return settings.Server
+	}
+	return ""
+}
+
+// SetWalletServer sets the node host:port of the open wallet and reconnects to it. It replaces the node given at
+// start for the rest of the session.
+func (w *WalletBE) SetWalletServer(server string) error {
+	settings, err := w.wallets.Get(w.walletName)
+	if err != nil {
+		return ErrNoWallet
+	}
+	settings.Server = server
+	if err := StoreWallets(w.walletsFilePath, w.wallets); err != nil {
+		return err
+	}
+
+	w.server = ""
+	w.connect()
+	return nil
+}
+
+// KDFParams returns the key derivation params used to encrypt new keys.
+func (w *WalletBE) KDFParams() crypto.KDParams {
+	return w.kdf
+}
+
+// SetKDFParams sets the key derivation params used to encrypt new keys in the data directory, see crypto.Calibrate.
+// Keys encrypted before keep their params.
+func (w *WalletBE) SetKDFParams(p crypto.KDParams) error {
+	p.Salt = ""
+	w.wallets.KDF = &p
+	if err := StoreWallets(w.walletsFilePath, w.wallets); err != nil {
+		return err
+	}
+
+	w.kdf = p
+	return nil
+}
+
+// SetNetworkID sets the network of the open wallet and stores it.
+func (w *WalletBE) SetNetworkID(id int8) error {
+	if w.walletName == "" {
+		return ErrNoWallet
+	}
+	w.meta.NetworkID = id
+	return w.StoreAccounts()
+}
+
+// WalletMetadata returns the wallet file metadata, including its integrity status.
+func (w *WalletBE) WalletMetadata() *accounts.Metadata {
+	return w.meta
+}
+
+// SetWalletPassphrase protects the wallet contents with passphrase and stores the wallet.
+func (w *WalletBE) SetWalletPassphrase(passphrase []byte) error {
+	if err := w.meta.SetPassphrase(passphrase, w.kdf); err != nil {
+		return err
+	}
+	return w.StoreAccounts()
 }
 
 func (w *WalletBE) CurrentAccount() *accounts.Account {
 	return w.currentAccount
 }
 
+// SetCurrentAccount sets a as the current account. The private key of the previous account is wiped.
 func (w *WalletBE) SetCurrentAccount(a *accounts.Account) {
+	if w.currentAccount != nil && w.currentAccount != a {
+		w.currentAccount.Lock()
+	}
 	w.currentAccount = a
 }
 
+// CreateAccount generates a new key pair using the key scheme named scheme and stores it as alias with the private
+// key encrypted by passphrase and bound to the wallet network. The returned account is unlocked.
+func (w *WalletBE) CreateAccount(alias, scheme string, passphrase []byte) (*accounts.Account, error) {
+	acc, err := w.Store.CreateAccount(alias, scheme, w.meta.NetworkID, passphrase, w.kdf)
+	if err != nil {
+		return nil, err
+	}
+	w.Audit("account-create", acc, map[string]string{"scheme": acc.Scheme.Name()})
+	return acc, nil
+}
+
+// ImportAccount stores the private key priv of scheme as alias, encrypted by passphrase and bound to the wallet
+// network. The returned account is unlocked and owns priv.
+func (w *WalletBE) ImportAccount(alias string, scheme accounts.KeyScheme, priv *crypto.Secret, passphrase []byte) (*accounts.Account, error) {
+	acc, err := w.Store.ImportAccount(alias, scheme, w.meta.NetworkID, priv, passphrase, w.kdf)
+	if err != nil {
+		return nil, err
+	}
+	w.Audit("account-import", acc, map[string]string{"scheme": acc.Scheme.Name()})
+	return acc, nil
+}
+
+// UnlockAccount decrypts the private key of a using passphrase. Keys bound to another network than the wallet's are
+// refused. Accounts stored unencrypted are encrypted with passphrase and persisted.
+func (w *WalletBE) UnlockAccount(a *accounts.Account, passphrase []byte) error {
+	if err := w.Store.CheckNetwork(a.Name, w.meta.NetworkID); err != nil {
+		return err
+	}
+	if err := w.Store.UnlockAccount(a, passphrase); err != nil {
+		return err
+	}
+	if w.Store.IsEncrypted(a.Name) {
+		return nil
+	}
+
+	if err := w.Store.EncryptAccount(a, w.meta.NetworkID, passphrase, w.kdf); err != nil {
+		a.Lock()
+		return err
+	}
+	return w.StoreAccounts()
+}
+
+// SignVRF computes the VRF output and proof of msg with the VRF key of account name, decrypted using the account
+// passphrase and wiped afterwards. The proof is recorded in the audit log.
+func (w *WalletBE) SignVRF(name string, passphrase, msg []byte) (output, proof []byte, err error) {
+	if err := w.Store.CheckNetwork(name, w.meta.NetworkID); err != nil {
+		return nil, nil, err
+	}
+	key, err := w.Store.UnlockVRFKey(name, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer key.Wipe()
+
+	if output, proof, err = crypto.NewVRFSigner(key.Bytes()).Prove(msg); err != nil {
+		return nil, nil, err
+	}
+	w.auditAccount("vrf-sign", name, messageDetails(msg))
+	return output, proof, nil
+}
+
+// Decrypt decrypts ciphertext with the private key of the unlocked account acc. The decryption is recorded in the
+// audit log.
+func (w *WalletBE) Decrypt(acc *accounts.Account, ciphertext []byte) ([]byte, error) {
+	plaintext, err := acc.Decrypt(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	w.Audit("decrypt", acc, messageDetails(ciphertext))
+	return plaintext, nil
+}
+
+// AccountInfo queries the node for the account nonce and balance and caches the returned balance.
+func (w *WalletBE) AccountInfo(address string) (*accounts.AccountInfo, error) {
+	info, err := w.HTTPRequester.AccountInfo(address)
+	if err != nil {
+		return nil, err
+	}
+	w.cacheBalance(address, info.Balance)
+	return info, nil
+}
+
+// CachedBalance returns the last balance fetched from the node for address, if any, including by previous runs.
+func (w *WalletBE) CachedBalance(address string) (string, bool) {
+	balance, ok := w.balances[strings.ToLower(strings.TrimPrefix(address, "0x"))]
+	return balance, ok
+}
+
 func InterfaceToBytes(i interface{}) ([]byte, error) {
 	var w bytes.Buffer
 	if _, err := xdr.Marshal(&w, &i); err != nil {
@@ -49,11 +416,223 @@ func InterfaceToBytes(i interface{}) ([]byte, error) {
 	return w.Bytes(), nil
 }
 
+// StoreAccounts stores the open wallet. A wallet without a wallet passphrase, new or upgraded from an older file
+// format, is first protected with the passphrase returned by the function set with SetNewWalletPassphrase.
 func (w *WalletBE) StoreAccounts() error {
-	return accounts.StoreAccounts(w.accountsFilePath, &w.Store)
+	if w.walletName == "" {
+		return ErrNoWallet
+	}
+	if !w.meta.IsProtected() && !w.meta.IsTampered() {
+		if w.newPassphrase == nil {
+			return accounts.ErrUnprotected
+		}
+		passphrase := w.newPassphrase()
+		if len(passphrase) == 0 {
+			return accounts.ErrUnprotected
+		}
+		defer crypto.Wipe(passphrase)
+		if err := w.meta.SetPassphrase(passphrase, w.kdf); err != nil {
+			return err
+		}
+	}
+	if err := accounts.StoreAccounts(w.accountsFilePath, &w.Store, w.meta); err != nil {
+		return err
+	}
+	return w.markProtected(w.walletName)
+}
+
+// DeleteAccount writes an encrypted tombstone backup of the account keys and removes the account from the store.
+// It returns the path of the tombstone file.
+func (w *WalletBE) DeleteAccount(name string, passphrase []byte) (string, error) {
+	backup, err := w.Store.WriteTombstone(path.Join(w.datadir, tombstonesDir), name, passphrase, w.kdf)
+	if err != nil {
+		return "", fmt.Errorf("failed to write tombstone backup: %v", err)
+	}
+
+	acc, err := w.Store.GetAccount(name)
+	if err != nil {
+		return "", err
+	}
+	if err := w.Store.DeleteAccount(name); err != nil {
+		return "", err
+	}
+	w.Audit("account-delete", acc, map[string]string{"backup": backup})
+	if w.currentAccount != nil && w.currentAccount.Name == name {
+		w.SetCurrentAccount(nil)
+	}
+
+	return backup, w.StoreAccounts()
+}
+
+// WritePaperWallet writes a printable paper wallet of account name to path, or to the data directory if path is
+// empty. withKey adds the encrypted keystore to the sheet. It returns the path of the written file.
+func (w *WalletBE) WritePaperWallet(name, filePath string, withKey bool) (string, error) {
+	keys, ok := w.Store[name]
+	if !ok {
+		return "", fmt.Errorf("account not found")
+	}
+	addr, err := keys.Address()
+	if err != nil {
+		return "", err
+	}
+	scheme, err := keys.KeyScheme()
+	if err != nil {
+		return "", err
+	}
+
+	sheet := &paper.Wallet{Alias: name, Scheme: scheme.Name(), Address: accounts.StringAddress(addr)}
+	if withKey {
+		if sheet.Keystore, err = w.Store.ExportKeystore(name); err != nil {
+			return "", err
+		}
+	}
+
+	if filePath == "" {
+		filePath = path.Join(w.datadir, fmt.Sprintf("paper-wallet-%s.txt", name))
+	}
+	if err := sheet.Write(filePath); err != nil {
+		return "", err
+	}
+	if withKey {
+		w.auditAccount("export", name, map[string]string{"format": "paper-wallet", "path": filePath})
+	}
+	return filePath, nil
+}
+
+func (w *WalletBE) StoreContacts() error {
+	return contacts.StoreBook(w.contactsFilePath, &w.Book)
+}
+
+// SetSigner delegates signing to s, e.g. a signer.Client of a signer daemon. A nil s signs with the unlocked
+// accounts.
+func (w *WalletBE) SetSigner(s signer.Signer) {
+	w.signer = s
+}
+
+// Signer returns the signer set by SetSigner.
+func (w *WalletBE) Signer() signer.Signer {
+	return w.signer
+}
+
+// DaemonSigner returns the signer a signer daemon serves the unlocked accounts accs with. It signs with Sign, so
+// every signature served is recorded in the audit log.
+func (w *WalletBE) DaemonSigner(accs ...*accounts.Account) signer.Signer {
+	return &daemonSigner{Local: signer.NewLocal(accs...), w: w, accs: accs}
+}
+
+// daemonSigner lists the keys of a signer.Local and signs through WalletBE.Sign.
+type daemonSigner struct {
+	*signer.Local
+	w    *WalletBE
+	accs []*accounts.Account
+}
+
+func (s *daemonSigner) Sign(pub, msg []byte) ([]byte, error) {
+	for _, acc := range s.accs {
+		if bytes.Equal(acc.PubKey, pub) {
+			return s.w.Sign(acc, msg)
+		}
+	}
+	return nil, fmt.Errorf("no key for public key %x", pub)
 }
 
-func (w *WalletBE) Transfer(recipient address.Address, nonce, amount, gasPrice, gasLimit uint64, key ed25519.PrivateKey) (string, error) {
+// SetNewWalletPassphrase sets the function asked for the passphrase protecting a wallet stored for the first time.
+func (w *WalletBE) SetNewWalletPassphrase(passphrase func() []byte) {
+	w.newPassphrase = passphrase
+}
+
+// SetTokenPIN sets the function asked for the PIN of a PKCS#11 token when it is first used.
+func (w *WalletBE) SetTokenPIN(pin func(token string) []byte) {
+	w.tokenPIN = pin
+}
+
+func (w *WalletBE) askTokenPIN(token string) []byte {
+	if w.tokenPIN == nil {
+		return nil
+	}
+	return w.tokenPIN(token)
+}
+
+// AddHSMAccount adds the account alias whose key is kept in a PKCS#11 token and stores the wallet. The public key is
+// read from the token.
+func (w *WalletBE) AddHSMAccount(alias string, key accounts.HSMKey) (*accounts.Account, error) {
+	pub, err := w.hsm.PublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := w.Store.AddHSMAccount(alias, key, pub)
+	if err != nil {
+		return nil, err
+	}
+	w.Audit("account-add-hsm", acc, map[string]string{"key": key.String()})
+	return acc, w.StoreAccounts()
+}
+
+// Sign signs msg with the key of account acc: by its token for accounts kept in PKCS#11 tokens, by the signer if
+// one is set and by the unlocked account otherwise. The signature is recorded in the audit log.
+func (w *WalletBE) Sign(acc *accounts.Account, msg []byte) ([]byte, error) {
+	sig, err := w.sign(acc, msg)
+	if err != nil {
+		return nil, err
+	}
+	w.Audit("sign", acc, messageDetails(msg))
+	return sig, nil
+}
+
+// sign signs msg like Sign without recording it, for callers writing their own audit log entry.
+func (w *WalletBE) sign(acc *accounts.Account, msg []byte) ([]byte, error) {
+	if key, ok := w.Store.HSMKey(acc.Name); ok {
+		w.hsm.Add(acc.Name, *key, acc.PubKey)
+		return w.hsm.Sign(acc.PubKey, msg)
+	}
+	if w.signer != nil {
+		return w.signer.Sign(acc.PubKey, msg)
+	}
+	return acc.Sign(msg)
+}
+
+// Transfer signs a transaction with the ed25519 key of account from and submits it to the node. Transfers breaking
+// the spending policy of from are refused unless allowed by OverrideSpending, as are transfers whose amount plus fee
+// exceed the balance of from. Transfers of accounts with an approval policy are only sent by TransferApproved.
+func (w *WalletBE) Transfer(recipient address.Address, nonce, amount, gasPrice, gasLimit uint64, from *accounts.Account) (string, error) {
+	policy, err := w.ApprovalPolicy(from.Name)
+	if err != nil {
+		return "", err
+	}
+	if policy != nil {
+		return "", fmt.Errorf("transfers of `%s` require approvals, use `approval create` and `approval broadcast`", from.Name)
+	}
+	return w.transfer(recipient, nonce, amount, gasPrice, gasLimit, from)
+}
+
+// CheckTransferKey returns an error if the key of account acc cannot sign transactions. Transactions carry no public
+// key, the node recovers it from their ed25519.Sign2 signature, which PKCS#11 tokens (CKM_EDDSA) cannot make.
+func (w *WalletBE) CheckTransferKey(acc *accounts.Account) error {
+	if acc.Scheme.Name() == accounts.HSMScheme {
+		return fmt.Errorf("account `%s` is kept in a PKCS#11 token, which cannot make the ed25519.Sign2 signatures of transactions", acc.Name)
+	}
+	if acc.Scheme.Name() != "ed25519" {
+		return fmt.Errorf("transactions can only be signed by ed25519 accounts, `%s` uses %s", acc.Name, acc.Scheme.Name())
+	}
+	return nil
+}
+
+// transfer signs and sends a transfer of from once its approval policy, if any, is satisfied.
+func (w *WalletBE) transfer(recipient address.Address, nonce, amount, gasPrice, gasLimit uint64, from *accounts.Account) (string, error) {
+	if err := w.CheckTransferKey(from); err != nil {
+		return "", err
+	}
+	t := spending.Transfer{From: from.Address(), To: recipient, Amount: amount, GasPrice: gasPrice}
+	if violations := w.spending.Check(t); len(violations) > 0 {
+		if w.override == nil || *w.override != t {
+			return "", fmt.Errorf("spending policy of `%s` refuses the transfer: %s", from.Name, strings.Join(violations, "; "))
+		}
+	}
+
+	if err := w.checkBalance(from.Address(), amount, gasPrice, gasLimit); err != nil {
+		return "", err
+	}
+
 	tx := SerializableSignedTransaction{}
 	tx.AccountNonce = nonce
 	tx.Amount = amount
@@ -62,10 +641,45 @@ func (w *WalletBE) Transfer(recipient address.Address, nonce, amount, gasPrice,
 	tx.Price = gasPrice
 
 	buf, _ := InterfaceToBytes(&tx.InnerSerializableSignedTransaction)
-	copy(tx.Signature[:], ed25519.Sign2(key, buf))
+	sig, err := w.sign(from, buf)
+	if err != nil {
+		return "", err
+	}
+	copy(tx.Signature[:], sig)
 	b, err := InterfaceToBytes(&tx)
 	if err != nil {
 		return "", err
 	}
-	return w.HTTPRequester.Send(b)
+
+	// the transfer counts against the caps before it is sent, so a failed write never lifts them
+	_, limited := w.spending.Policy(t.From)
+	if limited {
+		w.spending.Record(t, "")
+		if err := w.storeSpending(); err != nil {
+			w.spending.Cancel(t)
+			return "", fmt.Errorf("cannot record the transfer in the spending ledger: %v", err)
+		}
+	}
+	// an override is only used up once the transfer it allows is counted
+	w.override = nil
+	id, err := w.HTTPRequester.Send(b)
+	if err != nil {
+		if limited {
+			w.spending.Cancel(t)
+			if err := w.storeSpending(); err != nil {
+				log.Error("cannot remove the failed transfer from the spending ledger: %v", err)
+			}
+		}
+		return "", err
+	}
+
+	w.Audit("transfer", from, describeTransfer(&tx, id))
+	if limited {
+		w.spending.Cancel(t)
+		w.spending.Record(t, id)
+		if err := w.storeSpending(); err != nil {
+			log.Error("cannot store the id of transaction %s in the spending ledger: %v", id, err)
+		}
+	}
+	return id, nil
 }
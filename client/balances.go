@@ -0,0 +1,49 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/os/log"
+)
+
+// balancesFileName is the file in the data directory caching the last known balance of each account, so the
+// account picker shows balances before the node is queried.
+const balancesFileName = "balances.json"
+
+// loadBalances reads the cached balances by hex address from path. A missing or unreadable file yields an empty
+// cache.
+func loadBalances(path string) map[string]string {
+	balances := make(map[string]string)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return balances
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &balances)
+	}
+	if err != nil {
+		log.Error("cannot load cached balances from file %s: %v", path, err)
+		return make(map[string]string)
+	}
+	return balances
+}
+
+// cacheBalance records the balance of address and writes the cache when it changed. Failures are logged.
+func (w *WalletBE) cacheBalance(address, balance string) {
+	key := strings.ToLower(strings.TrimPrefix(address, "0x"))
+	if cached, ok := w.balances[key]; ok && cached == balance {
+		return
+	}
+	w.balances[key] = balance
+
+	data, err := json.MarshalIndent(w.balances, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(w.balancesFilePath, data, 0600)
+	}
+	if err != nil {
+		log.Error("cannot store cached balances to file %s: %v", w.balancesFilePath, err)
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedBalance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	node := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(rw).Encode(map[string]string{"value": "150"})
+	}))
+	defer node.Close()
+	be, err := NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+	addr := "0x2222222222222222222222222222222222222222"
+	_, ok := be.CachedBalance(addr)
+	assert.False(t, ok)
+	_, err = be.AccountInfo(addr)
+	assert.NoError(t, err)
+
+	// the balance is known to later runs before the node is queried
+	be, err = NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+	balance, ok := be.CachedBalance(strings.ToUpper(addr[2:]))
+	assert.True(t, ok)
+	assert.Equal(t, "150", balance)
+}
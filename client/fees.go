@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/libonomy/wallet-cli/fees"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+const (
+	feeLayers  = 10 // number of recent layers whose transactions are sampled
+	feeSamples = 50 // maximal number of sampled transactions
+)
+
+// feeTimeout bounds the time spent querying the node for an estimate.
+var feeTimeout = 5 * time.Second
+
+// feeCache is the last estimate computed from the transactions of the node at url up to layer.
+type feeCache struct {
+	url      string
+	layer    string
+	estimate fees.Estimate
+}
+
+// EstimateFees suggests gas prices from the transactions of the last layers known to the node. It falls back to
+// fees.Static when the node cannot be queried in time or knows too few transactions. Estimates are cached until the
+// node reaches a new layer.
+func (w *WalletBE) EstimateFees() fees.Estimate {
+	deadline := time.Now().Add(feeTimeout)
+	node, ok := w.feeRequester(deadline)
+	if !ok {
+		return fees.Static
+	}
+	info, err := node.NodeInfo()
+	if err != nil {
+		return fees.Static
+	}
+	if c := w.fees; c != nil && c.url == node.url && c.layer == info.CurrentLayer {
+		return c.estimate
+	}
+	layer, err := strconv.ParseUint(info.CurrentLayer, 10, 64)
+	if err != nil {
+		return fees.Static
+	}
+	if layer > feeLayers {
+		layer -= feeLayers
+	} else {
+		layer = 0
+	}
+
+	if node, ok = w.feeRequester(deadline); !ok {
+		return fees.Static
+	}
+	ids, err := node.TxsSinceLayer(layer)
+	if err != nil {
+		return fees.Static
+	}
+	if len(ids) > feeSamples {
+		ids = ids[len(ids)-feeSamples:]
+	}
+	prices := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if node, ok = w.feeRequester(deadline); !ok {
+			// a partial sample is not cached
+			return fees.FromPrices(prices)
+		}
+		if price, err := node.TxGasPrice(id); err == nil {
+			prices = append(prices, price)
+		}
+	}
+
+	estimate := fees.FromPrices(prices)
+	w.fees = &feeCache{url: node.url, layer: info.CurrentLayer, estimate: estimate}
+	return estimate
+}
+
+// feeRequester returns a requester to the node whose requests time out at deadline, or false if it passed.
+func (w *WalletBE) feeRequester(deadline time.Time) (*HTTPRequester, bool) {
+	left := time.Until(deadline)
+	if left <= 0 {
+		return nil, false
+	}
+	return &HTTPRequester{&http.Client{Timeout: left}, w.HTTPRequester.url}, true
+}
+
+// checkBalance returns an error unless the balance of addr covers amount plus the fee of gasPrice and gasLimit.
+func (w *WalletBE) checkBalance(addr address.Address, amount, gasPrice, gasLimit uint64) error {
+	total, _, err := fees.Total(amount, gasPrice, gasLimit)
+	if err != nil {
+		return err
+	}
+	info, err := w.AccountInfo(hex.EncodeToString(addr.Bytes()))
+	if err != nil {
+		return fmt.Errorf("cannot check the account balance: %v", err)
+	}
+	balance, err := strconv.ParseUint(info.Balance, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account balance `%s`: %v", info.Balance, err)
+	}
+	if total > balance {
+		return fmt.Errorf("amount plus fee %d exceeds the account balance %d", total, balance)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/fees"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+	"github.com/stretchr/testify/assert"
+)
+
+// feeNode serves the node API used by EstimateFees with transactions of the given gas prices.
+func feeNode(prices []uint64) *httptest.Server {
+	return httptest.NewServer(feeHandler(prices))
+}
+
+func feeHandler(prices []uint64) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		res := map[string]interface{}{}
+		switch req.URL.Path {
+		case "/v1/nodestatus":
+			res["currentLayer"] = "42"
+		case "/v1/gettxssincelayer":
+			if body["layerId"] != "32" {
+				http.Error(rw, "bad layer", http.StatusBadRequest)
+				return
+			}
+			txs := make([]string, len(prices))
+			for i := range prices {
+				txs[i] = fmt.Sprint(i)
+			}
+			res["txs"] = txs
+		case "/v1/gettransaction":
+			var i int
+			fmt.Sscan(body["id"], &i)
+			res["gasPrice"] = fmt.Sprint(prices[i])
+		}
+		_ = json.NewEncoder(rw).Encode(res)
+	}
+}
+
+func TestEstimateFees(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	node := feeNode([]uint64{4, 1, 3, 2, 5, 6, 7, 8, 9, 10})
+	defer node.Close()
+	be, err := NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, fees.Estimate{Low: 3, Normal: 5, Fast: 9, Samples: 10}, be.EstimateFees())
+
+	few := feeNode([]uint64{4, 1})
+	defer few.Close()
+	be, err = NewWalletBE(strings.TrimPrefix(few.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, fees.Static, be.EstimateFees())
+
+	node.Close()
+	be, err = NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, fees.Static, be.EstimateFees(), "unreachable node")
+}
+
+func TestEstimateFeesCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var lookups int32
+	handler := feeHandler([]uint64{4, 1, 3, 2, 5, 6, 7, 8, 9, 10})
+	node := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/gettransaction" {
+			atomic.AddInt32(&lookups, 1)
+		}
+		handler(rw, req)
+	}))
+	defer node.Close()
+	be, err := NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+
+	estimate := be.EstimateFees()
+	assert.Equal(t, int32(10), atomic.LoadInt32(&lookups))
+	assert.Equal(t, estimate, be.EstimateFees())
+	assert.Equal(t, int32(10), atomic.LoadInt32(&lookups), "estimates are cached until the next layer")
+}
+
+func TestEstimateFeesTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(d time.Duration) { feeTimeout = d }(feeTimeout)
+	feeTimeout = 200 * time.Millisecond
+
+	handler := feeHandler([]uint64{4, 1, 3, 2, 5, 6, 7, 8, 9, 10})
+	slow := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/gettransaction" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		handler(rw, req)
+	}))
+	defer slow.Close()
+	be, err := NewWalletBE(strings.TrimPrefix(slow.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.Equal(t, fees.Static, be.EstimateFees())
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Nil(t, be.fees, "partial estimates are not cached")
+}
+
+func TestTransferBalance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(p crypto.KDParams) { crypto.DefaultCypherParams = p }(crypto.DefaultCypherParams)
+	crypto.DefaultCypherParams.N = 1024
+
+	node := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(rw).Encode(map[string]string{"value": "150"})
+	}))
+	defer node.Close()
+	be, err := NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", nil)
+	assert.NoError(t, err)
+	acc, err := be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+	bob := address.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	_, err = be.Transfer(bob, 0, 51, 1, 100, acc)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the account balance 150")
+}
+
+func TestTransferTokenAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	be, err := NewWalletBE("", dir, "", nil)
+	assert.NoError(t, err)
+	acc, err := be.Store.AddHSMAccount("vault", accounts.HSMKey{Module: "softhsm2.so", Token: "ops", KeyID: "01"}, make([]byte, 32))
+	assert.NoError(t, err)
+	bob := address.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	// refused before the node is queried for the balance
+	assert.Error(t, be.CheckTransferKey(acc))
+	_, err = be.Transfer(bob, 0, 1, 1, 100, acc)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "PKCS#11 token")
+}
@@ -230,6 +230,44 @@ func (m HTTPRequester) ListTxs(address string) ([]string, error) {
 	return txs, nil
 }
 
+// TxsSinceLayer returns the ids of the transactions of the layers since layer.
+func (m HTTPRequester) TxsSinceLayer(layer uint64) ([]string, error) {
+	str := fmt.Sprintf(`{ "layerId": "%d"}`, layer)
+	res, err := m.Get("/gettxssincelayer", str, false)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]string, 0)
+	val, ok := res["txs"].([]interface{})
+	if !ok {
+		return txs, nil
+	}
+	for _, id := range val {
+		if id, ok := id.(string); ok {
+			txs = append(txs, id)
+		}
+	}
+	return txs, nil
+}
+
+// TxGasPrice returns the gas price of the transaction id.
+func (m HTTPRequester) TxGasPrice(id string) (uint64, error) {
+	str := fmt.Sprintf(`{ "id": "%s"}`, id)
+	res, err := m.Get("/gettransaction", str, false)
+	if err != nil {
+		return 0, err
+	}
+
+	switch val := res["gasPrice"].(type) {
+	case string:
+		return strconv.ParseUint(val, 10, 64)
+	case float64:
+		return uint64(val), nil
+	}
+	return 0, fmt.Errorf("no gas price in transaction %s", id)
+}
+
 func (m HTTPRequester) SetCoinbase(coinbase string) error {
 	str := fmt.Sprintf(`{ "address": "%s"}`, coinbase)
 	_, err := m.Get("/setawardsaddr", str, true)
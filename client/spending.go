@@ -0,0 +1,122 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/spending"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// spendingSection is the wallet section holding the spending ledger.
+const spendingSection = "spending"
+
+// storeSpending stores the spending ledger in the wallet file.
+func (w *WalletBE) storeSpending() error {
+	if err := w.meta.SetSection(spendingSection, w.spending); err != nil {
+		return err
+	}
+	return w.StoreAccounts()
+}
+
+// VerifyPassphrase checks passphrase decrypts the private key of account name, which stays locked.
+func (w *WalletBE) VerifyPassphrase(name string, passphrase []byte) error {
+	if !w.Store.IsEncrypted(name) {
+		return fmt.Errorf("account `%s` has no passphrase", name)
+	}
+	if err := w.Store.CheckNetwork(name, w.meta.NetworkID); err != nil {
+		return err
+	}
+	acc, err := w.Store.GetAccount(name)
+	if err != nil {
+		return err
+	}
+	if err := w.Store.UnlockAccount(acc, passphrase); err != nil {
+		return err
+	}
+	acc.Lock()
+	return nil
+}
+
+// SpendingPolicy returns the spending policy of account name, if any, and the amounts it sent over the last day and
+// week.
+func (w *WalletBE) SpendingPolicy(name string) (p *spending.Policy, spentDay, spentWeek uint64, err error) {
+	addr, err := w.Store.AccountAddress(name)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	p, _ = w.spending.Policy(addr)
+	return p, w.spending.Spent(addr, spending.Day), w.spending.Spent(addr, spending.Week), nil
+}
+
+// SetSpendingPolicy sets the spending policy of account name, an empty policy removes it, and stores it in the wallet
+// file. Changing an existing policy requires the account passphrase and is recorded in the audit log.
+func (w *WalletBE) SetSpendingPolicy(name string, p *spending.Policy, passphrase []byte) error {
+	addr, err := w.Store.AccountAddress(name)
+	if err != nil {
+		return err
+	}
+	old, exists := w.spending.Policy(addr)
+	if exists {
+		if err := w.VerifyPassphrase(name, passphrase); err != nil {
+			return err
+		}
+	}
+	if err := w.spending.SetPolicy(addr, p); err != nil {
+		return err
+	}
+	restore := func() {
+		if exists {
+			_ = w.spending.SetPolicy(addr, old)
+		} else {
+			_ = w.spending.SetPolicy(addr, &spending.Policy{})
+		}
+	}
+
+	details := map[string]string{"alias": name, "policy": p.String()}
+	if exists {
+		details["previous"] = old.String()
+	}
+	if err := w.record("spending-policy", accounts.StringAddress(addr), details); err != nil {
+		restore()
+		return fmt.Errorf("cannot write audit log: %v", err)
+	}
+	if err := w.storeSpending(); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+// CheckSpending returns the rules of the spending policy of from the transfer breaks, if any.
+func (w *WalletBE) CheckSpending(from *accounts.Account, recipient address.Address, amount, gasPrice uint64) []string {
+	return w.spending.Check(spending.Transfer{From: from.Address(), To: recipient, Amount: amount, GasPrice: gasPrice})
+}
+
+// OverrideSpending allows the next Transfer of from, if it is exactly the given transfer, despite the spending
+// policy. It requires the passphrase of from and the override is recorded in the audit log.
+func (w *WalletBE) OverrideSpending(from *accounts.Account, recipient address.Address, amount, gasPrice uint64, passphrase []byte) error {
+	t := spending.Transfer{From: from.Address(), To: recipient, Amount: amount, GasPrice: gasPrice}
+	violations := w.spending.Check(t)
+	if len(violations) == 0 {
+		return nil
+	}
+	if err := w.VerifyPassphrase(from.Name, passphrase); err != nil {
+		return err
+	}
+
+	details := map[string]string{
+		"alias":      from.Name,
+		"recipient":  accounts.StringAddress(recipient),
+		"amount":     strconv.FormatUint(amount, 10),
+		"gasPrice":   strconv.FormatUint(gasPrice, 10),
+		"violations": strings.Join(violations, "; "),
+	}
+	if err := w.record("spending-override", accounts.StringAddress(t.From), details); err != nil {
+		return fmt.Errorf("cannot write audit log: %v", err)
+	}
+	w.override = &t
+	return nil
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/audit"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/spending"
+	"github.com/libonomy/wallet-cli/wallet/address"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletBESpending(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(p crypto.KDParams) { crypto.DefaultCypherParams = p }(crypto.DefaultCypherParams)
+	crypto.DefaultCypherParams.N = 1024
+
+	be, err := NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	be.SetNewWalletPassphrase(walletPassphrase)
+	acc, err := be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+	bob := address.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	assert.NoError(t, be.SetSpendingPolicy("alice", &spending.Policy{MaxAmount: 10}, nil), "first policy")
+	assert.Error(t, be.SetSpendingPolicy("alice", &spending.Policy{MaxAmount: 20}, []byte("wrong")))
+	p, _, _, err := be.SpendingPolicy("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), p.MaxAmount)
+
+	assert.Empty(t, be.CheckSpending(acc, bob, 10, 1))
+	assert.Len(t, be.CheckSpending(acc, bob, 11, 1), 1)
+	_, err = be.Transfer(bob, 0, 11, 1, 100, acc)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spending policy")
+
+	assert.Error(t, be.OverrideSpending(acc, bob, 11, 1, []byte("wrong")))
+	assert.Nil(t, be.override)
+	assert.NoError(t, be.OverrideSpending(acc, bob, 11, 1, []byte("beagles")))
+	assert.Equal(t, &spending.Transfer{From: acc.Address(), To: bob, Amount: 11, GasPrice: 1}, be.override)
+	_, err = be.Transfer(bob, 0, 11, 1, 100, acc)
+	assert.Error(t, err, "no node to check the balance")
+	assert.NotNil(t, be.override, "a transfer failing before it is recorded keeps the override")
+
+	log, err := ioutil.ReadFile(be.audit.Path())
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(log)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"event":"account-create"`)
+	assert.Contains(t, lines[1], `"event":"spending-policy"`)
+	assert.Contains(t, lines[2], `"event":"spending-override"`)
+	assert.Equal(t, path.Join(dir, "logs", audit.FileName), be.audit.Path())
+
+	// the ledger is reloaded with the wallet and authenticated with it
+	be, err = NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	assert.Len(t, be.CheckSpending(acc, bob, 11, 1), 1)
+	assert.NoError(t, be.StoreAccounts())
+	be, err = NewWalletBE("", dir, "", func() []byte { return []byte("wrong") })
+	assert.NoError(t, err)
+	assert.True(t, be.WalletMetadata().IsTampered())
+	assert.Error(t, be.SetSpendingPolicy("alice", &spending.Policy{}, []byte("beagles")), "tampered wallets are read-only")
+}
+
+func TestSpendingRecordedBeforeSend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(p crypto.KDParams) { crypto.DefaultCypherParams = p }(crypto.DefaultCypherParams)
+	crypto.DefaultCypherParams.N = 1024
+
+	var accepted []string // ledger histories seen by the node when transactions are submitted
+	fail := true
+	var be *WalletBE
+	node := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/submittransaction" {
+			_ = json.NewEncoder(rw).Encode(map[string]string{"value": "1000"})
+			return
+		}
+		data, _ := ioutil.ReadFile(be.accountsFilePath)
+		accepted = append(accepted, string(data))
+		if fail {
+			http.Error(rw, "rejected", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]string{"id": "tx1"})
+	}))
+	defer node.Close()
+
+	be, err = NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	be.SetNewWalletPassphrase(walletPassphrase)
+	acc, err := be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+	bob := address.HexToAddress("0x2222222222222222222222222222222222222222")
+	assert.NoError(t, be.SetSpendingPolicy("alice", &spending.Policy{DailyCap: 100}, nil))
+
+	_, err = be.Transfer(bob, 0, 10, 1, 1, acc)
+	assert.Error(t, err)
+	assert.Len(t, accepted, 1)
+	assert.Contains(t, accepted[0], `"amount": 10`, "the transfer is stored before it is sent")
+	_, day, _, err := be.SpendingPolicy("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), day, "failed transfers are not counted")
+
+	fail = false
+	id, err := be.Transfer(bob, 0, 10, 1, 1, acc)
+	assert.NoError(t, err)
+	assert.Equal(t, "tx1", id)
+	assert.NoError(t, be.OverrideSpending(acc, bob, 200, 1, []byte("beagles")))
+	_, err = be.Transfer(bob, 0, 200, 1, 1, acc)
+	assert.NoError(t, err)
+	assert.Nil(t, be.override, "the override is used up by the transfer it allows")
+	be, err = NewWalletBE(strings.TrimPrefix(node.URL, "http://"), dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	_, day, _, err = be.SpendingPolicy("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(210), day)
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+)
+
+const (
+	// DefaultWalletName is the name of the wallet stored in accounts.json, used when no wallet is configured.
+	DefaultWalletName = "default"
+
+	walletsFileName = "wallets.json"
+	walletsDir      = "wallets"
+)
+
+var walletNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// WalletSettings holds the per-wallet settings.
+type WalletSettings struct {
+	File      string `json:"file"`                // wallet file path relative to the data directory
+	Server    string `json:"server,omitempty"`    // host:port of the node, DefaultNodeHostPort if empty
+	Protected bool   `json:"protected,omitempty"` // the wallet file was stored with a wallet passphrase
+}
+
+// Wallets holds the named wallets of a data directory, the wallet opened at start and the key derivation params
+// used to encrypt new keys.
+type Wallets struct {
+	Default string                     `json:"default"`
+	Wallets map[string]*WalletSettings `json:"wallets"`
+	KDF     *crypto.KDParams           `json:"kdf,omitempty"` // crypto.DefaultCypherParams if nil
+}
+
+func newWallets() *Wallets {
+	return &Wallets{
+		Default: DefaultWalletName,
+		Wallets: map[string]*WalletSettings{DefaultWalletName: {File: accountsFileName}},
+	}
+}
+
+// LoadWallets reads the wallet settings from path. A missing file yields the settings of the default wallet.
+func LoadWallets(path string) (*Wallets, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newWallets(), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	w := &Wallets{}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+	if w.Wallets == nil {
+		w.Wallets = make(map[string]*WalletSettings)
+	}
+	return w, nil
+}
+
+// StoreWallets writes the wallet settings to path.
+func StoreWallets(path string, w *Wallets) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Add registers a new wallet called name, stored in the wallets directory.
+func (w *Wallets) Add(name string) (*WalletSettings, error) {
+	if !walletNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid wallet name `%s`, use up to 32 letters, digits, `-` or `_`", name)
+	}
+	if _, ok := w.Wallets[name]; ok {
+		return nil, fmt.Errorf("wallet `%s` already exists", name)
+	}
+
+	s := &WalletSettings{File: path.Join(walletsDir, name+".json")}
+	w.Wallets[name] = s
+	return s, nil
+}
+
+// Get returns the settings of wallet name.
+func (w *Wallets) Get(name string) (*WalletSettings, error) {
+	s, ok := w.Wallets[name]
+	if !ok {
+		return nil, fmt.Errorf("wallet `%s` not found", name)
+	}
+	return s, nil
+}
+
+// SetDefault sets wallet name as the one opened at start.
+func (w *Wallets) SetDefault(name string) error {
+	if _, err := w.Get(name); err != nil {
+		return err
+	}
+	w.Default = name
+	return nil
+}
+
+// List returns the sorted wallet names.
+func (w *Wallets) List() []string {
+	names := make([]string, 0, len(w.Wallets))
+	for name := range w.Wallets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/signer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWalletsDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := LoadWallets(path.Join(dir, walletsFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWalletName, w.Default)
+	assert.Equal(t, []string{DefaultWalletName}, w.List())
+
+	s, err := w.Get(DefaultWalletName)
+	assert.NoError(t, err)
+	assert.Equal(t, accountsFileName, s.File)
+}
+
+func TestWalletsAddAndStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w := newWallets()
+	_, err = w.Add("bad name")
+	assert.Error(t, err)
+	_, err = w.Add(DefaultWalletName)
+	assert.Error(t, err)
+
+	s, err := w.Add("team")
+	assert.NoError(t, err)
+	s.Server = "10.0.0.1:9090"
+	assert.NoError(t, w.SetDefault("team"))
+	assert.Error(t, w.SetDefault("missing"))
+
+	filePath := path.Join(dir, walletsFileName)
+	assert.NoError(t, StoreWallets(filePath, w))
+	loaded, err := LoadWallets(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, w, loaded)
+	assert.Equal(t, []string{DefaultWalletName, "team"}, loaded.List())
+}
+
+func walletPassphrase() []byte {
+	return []byte("wallet passphrase")
+}
+
+func TestWalletBEOpenClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	be, err := NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWalletName, be.WalletName())
+	assert.Equal(t, "http://"+DefaultNodeHostPort+"/v1", be.NodeURL())
+
+	assert.NoError(t, be.CreateWallet("team", walletPassphrase()))
+	assert.Error(t, be.CreateWallet("team", walletPassphrase()))
+	assert.NoError(t, be.OpenWallet("team"))
+	assert.NoError(t, be.SetWalletServer("10.0.0.1:9090"))
+	assert.Equal(t, "http://10.0.0.1:9090/v1", be.NodeURL())
+	assert.NoError(t, be.SetNetworkID(7))
+
+	be.CloseWallet()
+	assert.Equal(t, "", be.WalletName())
+	assert.Equal(t, ErrNoWallet, be.StoreAccounts())
+	assert.Equal(t, ErrNoWallet, be.SetNetworkID(1))
+
+	// settings and wallet contents survive a restart
+	assert.NoError(t, be.SetDefaultWallet("team"))
+	be, err = NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, "team", be.WalletName())
+	assert.Equal(t, "http://10.0.0.1:9090/v1", be.NodeURL())
+	assert.Equal(t, int8(7), be.WalletMetadata().NetworkID)
+
+	// --server overrides the wallet node
+	be, err = NewWalletBE("localhost:1234", dir, DefaultWalletName, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWalletName, be.WalletName())
+	assert.Equal(t, "http://localhost:1234/v1", be.NodeURL())
+}
+
+func TestWalletBEDowngrade(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	be, err := NewWalletBE("", dir, "", walletPassphrase)
+	assert.NoError(t, err)
+	assert.NoError(t, be.CreateWallet("team", walletPassphrase()))
+	settings, err := be.wallets.Get("team")
+	assert.NoError(t, err)
+	assert.True(t, settings.Protected)
+
+	// a protected wallet rewritten in a format without integrity tags must not load as a legacy wallet
+	filePath := path.Join(dir, settings.File)
+	for version, data := range map[string]string{
+		"v1": `{"mallory":{"pubkey":"aa","privkey":"bb"}}`,
+		"v2": `{"version":2,"created":"2020-01-01T00:00:00Z","networkId":1,"accounts":{"mallory":{"pubkey":"aa","privkey":"bb"}}}`,
+	} {
+		assert.NoError(t, ioutil.WriteFile(filePath, []byte(data), 0600))
+		assert.Equal(t, accounts.ErrTampered, be.OpenWallet("team"), version)
+		assert.True(t, be.WalletMetadata().IsTampered(), version)
+		assert.False(t, be.WalletMetadata().IsLegacy(), version)
+		assert.Error(t, be.StoreAccounts(), version)
+	}
+
+	// the settings of wallets protected before they were recorded are updated when the wallet is opened
+	settings.Protected = false
+	assert.NoError(t, StoreWallets(be.walletsFilePath, be.wallets))
+	assert.NoError(t, be.CreateWallet("ops", walletPassphrase()))
+	ops, err := be.wallets.Get("ops")
+	assert.NoError(t, err)
+	ops.Protected = false
+	assert.NoError(t, be.OpenWallet("ops"))
+	assert.True(t, ops.Protected)
+}
+
+func TestSetKDFParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	be, err := NewWalletBE("", dir, "", nil)
+	assert.NoError(t, err)
+	p := crypto.KDParams{Algorithm: crypto.KDFArgon2id, Time: 1, Memory: 1024, Threads: 1, SaltLen: 16, DKLen: 32}
+	assert.NoError(t, be.SetKDFParams(p))
+	assert.Equal(t, p, be.KDFParams())
+
+	// the params are applied when the data directory is opened again, without changing the package defaults
+	defaults := crypto.DefaultCypherParams
+	be, err = NewWalletBE("", dir, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, p, be.KDFParams())
+	assert.Equal(t, defaults, crypto.DefaultCypherParams)
+
+	_, err = be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+	used := be.Store["alice"].Crypto.KDParams
+	used.Salt = ""
+	assert.Equal(t, p, used)
+}
+
+func TestWalletBESigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(p crypto.KDParams) { crypto.DefaultCypherParams = p }(crypto.DefaultCypherParams)
+	crypto.DefaultCypherParams.N = 1024
+
+	be, err := NewWalletBE("", dir, "", nil)
+	assert.NoError(t, err)
+	acc, err := be.CreateAccount("alice", "ed25519", []byte("beagles"))
+	assert.NoError(t, err)
+	held, err := be.GetAccount("alice")
+	assert.NoError(t, err)
+	assert.NoError(t, be.UnlockAccount(held, []byte("beagles")))
+
+	msg := []byte("message")
+	acc.Lock()
+	_, err = be.Sign(acc, msg)
+	assert.Error(t, err, "locked account without a signer")
+
+	// the signer holds the key, the wallet account stays locked
+	be.SetSigner(signer.NewLocal(held))
+	sig, err := be.Sign(acc, msg)
+	assert.NoError(t, err)
+	assert.True(t, acc.Verify(msg, sig))
+	assert.True(t, acc.IsLocked())
+}
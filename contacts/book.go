@@ -0,0 +1,142 @@
+// Package contacts provides a persistent address book of named libonomy addresses.
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/os/log"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// Contact is a named address stored in the address book.
+type Contact struct {
+	Name    string    `json:"name"`
+	Address string    `json:"address"`
+	Note    string    `json:"note,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Book maps contact names to contacts.
+type Book map[string]Contact
+
+// StoreBook persists the address book to path.
+func StoreBook(path string, book *Book) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	defer w.Close()
+	if err := enc.Encode(book); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LoadBook reads the address book stored at path.
+func LoadBook(path string) (*Book, error) {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		log.Warning("address book not loaded since file does not exist. file=%v", path)
+		return nil, err
+	}
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer r.Close()
+
+	dec := json.NewDecoder(r)
+	book := &Book{}
+	if err := dec.Decode(book); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// AddContact adds a new named address to the book.
+func (b Book) AddContact(name, addr, note string) (*Contact, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("contact name cannot be empty")
+	}
+	if _, ok := b[name]; ok {
+		return nil, fmt.Errorf("contact `%s` already exists", name)
+	}
+	if !address.IsHexAddress(addr) {
+		return nil, fmt.Errorf("invalid address `%s`", addr)
+	}
+
+	c := Contact{
+		Name:    name,
+		Address: address.HexToAddress(addr).Hex(),
+		Note:    strings.TrimSpace(note),
+		Created: time.Now().UTC(),
+	}
+	b[name] = c
+	return &c, nil
+}
+
+// RemoveContact deletes a contact from the book.
+func (b Book) RemoveContact(name string) error {
+	if _, ok := b[name]; !ok {
+		return fmt.Errorf("contact not found")
+	}
+	delete(b, name)
+	return nil
+}
+
+// RenameContact changes the name of an existing contact.
+func (b Book) RenameContact(name, newName string) error {
+	c, ok := b[name]
+	if !ok {
+		return fmt.Errorf("contact not found")
+	}
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("contact name cannot be empty")
+	}
+	if _, ok := b[newName]; ok {
+		return fmt.Errorf("contact `%s` already exists", newName)
+	}
+
+	c.Name = newName
+	delete(b, name)
+	b[newName] = c
+	return nil
+}
+
+// GetContact returns the contact stored under name.
+func (b Book) GetContact(name string) (*Contact, error) {
+	if c, ok := b[name]; ok {
+		return &c, nil
+	}
+	return nil, fmt.Errorf("contact not found")
+}
+
+// ContactByAddress returns the contact holding addr, if any.
+func (b Book) ContactByAddress(addr address.Address) (*Contact, bool) {
+	for _, c := range b {
+		if address.HexToAddress(c.Address) == addr {
+			return &c, true
+		}
+	}
+	return nil, false
+}
+
+// ListContacts returns all contacts sorted by name.
+func (b Book) ListContacts() []Contact {
+	lst := make([]Contact, 0, len(b))
+	for _, c := range b {
+		lst = append(lst, c)
+	}
+	sort.Slice(lst, func(i, j int) bool { return lst[i].Name < lst[j].Name })
+	return lst
+}
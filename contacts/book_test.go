@@ -0,0 +1,65 @@
+package contacts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/wallet/address"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAddress = "0x1b0ec2da7cd8aa8d2ba1bbda4b0d3b1a0af5a4f0"
+
+func TestBookOps(t *testing.T) {
+	book := Book{}
+
+	_, err := book.AddContact("alice", "0x1234", "")
+	assert.Error(t, err, "expected invalid address error")
+
+	c, err := book.AddContact("alice", testAddress, " team lead ")
+	assert.NoError(t, err)
+	assert.Equal(t, "team lead", c.Note)
+	assert.Equal(t, address.HexToAddress(testAddress).Hex(), c.Address)
+
+	_, err = book.AddContact("alice", testAddress, "")
+	assert.Error(t, err, "expected duplicate name error")
+
+	found, ok := book.ContactByAddress(address.HexToAddress(testAddress))
+	assert.True(t, ok)
+	assert.Equal(t, "alice", found.Name)
+
+	assert.NoError(t, book.RenameContact("alice", "bob"))
+	_, err = book.GetContact("alice")
+	assert.Error(t, err)
+	found, err = book.GetContact("bob")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", found.Name)
+
+	assert.NoError(t, book.RemoveContact("bob"))
+	assert.Error(t, book.RemoveContact("bob"))
+	assert.Empty(t, book.ListContacts())
+}
+
+func TestStoreAndLoadBook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contacts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	book := Book{}
+	_, err = book.AddContact("carol", testAddress, "")
+	assert.NoError(t, err)
+	_, err = book.AddContact("alice", "0x"+"ab"+testAddress[4:], "")
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "contacts.json")
+	assert.NoError(t, StoreBook(path, &book))
+
+	loaded, err := LoadBook(path)
+	assert.NoError(t, err)
+	lst := loaded.ListContacts()
+	assert.Len(t, lst, 2)
+	assert.Equal(t, "alice", lst[0].Name)
+	assert.Equal(t, "carol", lst[1].Name)
+}
@@ -0,0 +1,82 @@
+// Package fees suggests transaction gas prices from the gas prices of recent transactions.
+package fees
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MinSamples is the number of recent gas prices required to estimate fees, fewer fall back to Static.
+const MinSamples = 5
+
+// Estimate holds suggested gas prices per gas unit, in Smidge.
+type Estimate struct {
+	Low     uint64
+	Normal  uint64
+	Fast    uint64
+	Samples int // number of recent transactions the estimate is based on, 0 for the static table
+}
+
+// Static is the fallback estimate used when too few recent transactions are known.
+var Static = Estimate{Low: 1, Normal: 1, Fast: 2}
+
+// FromPrices estimates fees from the gas prices of recent transactions: the 25th percentile for low, the median for
+// normal and the 90th percentile for fast. It returns Static if fewer than MinSamples prices are given.
+func FromPrices(prices []uint64) Estimate {
+	if len(prices) < MinSamples {
+		return Static
+	}
+
+	sorted := append([]uint64{}, prices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p int) uint64 {
+		price := sorted[(len(sorted)-1)*p/100]
+		if price == 0 {
+			return 1
+		}
+		return price
+	}
+	return Estimate{Low: percentile(25), Normal: percentile(50), Fast: percentile(90), Samples: len(prices)}
+}
+
+// Preset returns the gas price of the preset name: low, normal or fast, or their first letter.
+func (e Estimate) Preset(name string) (uint64, bool) {
+	switch name {
+	case "l", "low":
+		return e.Low, true
+	case "n", "normal":
+		return e.Normal, true
+	case "f", "fast":
+		return e.Fast, true
+	}
+	return 0, false
+}
+
+// String returns the suggested gas prices and their source.
+func (e Estimate) String() string {
+	source := "static table"
+	if e.Samples > 0 {
+		source = fmt.Sprintf("%d recent transactions", e.Samples)
+	}
+	return fmt.Sprintf("low %d, normal %d, fast %d (from %s)", e.Low, e.Normal, e.Fast, source)
+}
+
+// Fee returns the maximal fee of a transaction, gasPrice * gasLimit.
+func Fee(gasPrice, gasLimit uint64) (uint64, error) {
+	if gasLimit != 0 && gasPrice > ^uint64(0)/gasLimit {
+		return 0, fmt.Errorf("fee of gas price %d and gas limit %d overflows", gasPrice, gasLimit)
+	}
+	return gasPrice * gasLimit, nil
+}
+
+// Total returns amount plus the fee of gasPrice and gasLimit, and the fee.
+func Total(amount, gasPrice, gasLimit uint64) (total, fee uint64, err error) {
+	fee, err = Fee(gasPrice, gasLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	if amount+fee < amount {
+		return 0, 0, fmt.Errorf("amount %d plus fee %d overflows", amount, fee)
+	}
+	return amount + fee, fee, nil
+}
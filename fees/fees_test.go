@@ -0,0 +1,39 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromPrices(t *testing.T) {
+	assert.Equal(t, Static, FromPrices(nil))
+	assert.Equal(t, Static, FromPrices([]uint64{5, 6, 7, 8}))
+
+	prices := []uint64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5, 0}
+	e := FromPrices(prices)
+	assert.Equal(t, Estimate{Low: 2, Normal: 5, Fast: 9, Samples: 11}, e)
+	assert.Equal(t, uint64(10), prices[0], "prices are not reordered")
+
+	e = FromPrices([]uint64{0, 0, 0, 0, 0})
+	assert.Equal(t, uint64(1), e.Low, "at least 1")
+
+	price, ok := e.Preset("fast")
+	assert.True(t, ok)
+	assert.Equal(t, e.Fast, price)
+	_, ok = e.Preset("slow")
+	assert.False(t, ok)
+	assert.Contains(t, Static.String(), "static table")
+}
+
+func TestTotal(t *testing.T) {
+	total, fee, err := Total(1000, 2, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), fee)
+	assert.Equal(t, uint64(1200), total)
+
+	_, _, err = Total(1, ^uint64(0), 2)
+	assert.Error(t, err)
+	_, _, err = Total(^uint64(0), 1, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+// Package filesig creates and verifies detached signatures of files. Files are streamed through a hash function
+// and the digest is signed by a wallet account.
+package filesig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto/sha3"
+)
+
+// Digest algorithms.
+const (
+	SHA3256 = "sha3-256"
+	SHA256  = "sha256"
+)
+
+// Ext is appended to the signed file path to name its detached signature file.
+const Ext = ".sig.json"
+
+// Signature is a detached signature of a file digest.
+type Signature struct {
+	Algorithm string    `json:"algorithm"`
+	Digest    string    `json:"digest"` // hex encoded
+	Scheme    string    `json:"scheme"`
+	Signer    string    `json:"signer"` // address of the signing account
+	PubKey    string    `json:"pubkey"`
+	Signature string    `json:"signature"`
+	Created   time.Time `json:"created"` // informational, not covered by the signature
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case SHA3256:
+		return sha3.New256(), nil
+	case SHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm %s", algorithm)
+	}
+}
+
+// Digest returns the digest of the file at path, read in a streaming fashion.
+func Digest(path, algorithm string) ([]byte, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// Sign returns a detached signature of the file at path by acc signed with sign, e.g. WalletBE.Sign.
+func Sign(path, algorithm string, acc *accounts.Account, sign func(*accounts.Account, []byte) ([]byte, error)) (*Signature, error) {
+	digest, err := Digest(path, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign(acc, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signature{
+		Algorithm: algorithm,
+		Digest:    hex.EncodeToString(digest),
+		Scheme:    acc.Scheme.Name(),
+		Signer:    accounts.StringAddress(acc.Address()),
+		PubKey:    acc.Scheme.EncodeKey(acc.PubKey),
+		Signature: hex.EncodeToString(sig),
+		Created:   time.Now().UTC(),
+	}, nil
+}
+
+// Verify checks that s is a valid signature of the file at path. The signer address must match the public key.
+func (s *Signature) Verify(path string) error {
+	scheme, err := accounts.GetScheme(s.Scheme)
+	if err != nil {
+		return err
+	}
+	pub, err := scheme.ParsePublicKey(s.PubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	if !strings.EqualFold(accounts.StringAddress(scheme.Address(pub)), s.Signer) {
+		return fmt.Errorf("signer address %s does not match the public key", s.Signer)
+	}
+	sig, err := hex.DecodeString(s.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	digest, err := Digest(path, s.Algorithm)
+	if err != nil {
+		return err
+	}
+	if expected, err := hex.DecodeString(s.Digest); err != nil || !bytes.Equal(expected, digest) {
+		return fmt.Errorf("file digest does not match the signed digest")
+	}
+	if !scheme.Verify(pub, digest, sig) {
+		return fmt.Errorf("signature is not valid")
+	}
+	return nil
+}
+
+// Write writes s to path.
+func (s *Signature) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Read reads a detached signature from path.
+func Read(path string) (*Signature, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signature{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,88 @@
+package filesig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(acc *accounts.Account, msg []byte) ([]byte, error) {
+	return acc.Sign(msg)
+}
+
+func TestMain(m *testing.M) {
+	crypto.DefaultCypherParams.N = 1024
+	os.Exit(m.Run())
+}
+
+func TestDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "release.tar.gz")
+	data := make([]byte, 1<<20+7)
+	assert.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	digest, err := Digest(path, SHA256)
+	assert.NoError(t, err)
+	expected := sha256.Sum256(data)
+	assert.Equal(t, expected[:], digest)
+
+	digest, err = Digest(path, SHA3256)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Sha256(data), digest)
+
+	_, err = Digest(path, "md5")
+	assert.Error(t, err)
+}
+
+func TestSignVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "release.tar.gz")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("release 1.0"), 0600))
+
+	for _, scheme := range accounts.SchemeNames() {
+		s := accounts.Store{}
+		acc, err := s.CreateAccount("alice", scheme, 0, []byte("beagles"), crypto.DefaultCypherParams)
+		assert.NoError(t, err)
+
+		for _, algorithm := range []string{SHA3256, SHA256} {
+			sig, err := Sign(path, algorithm, acc, sign)
+			assert.NoError(t, err)
+			assert.Equal(t, accounts.StringAddress(acc.Address()), sig.Signer)
+
+			assert.NoError(t, sig.Write(path+Ext))
+			read, err := Read(path + Ext)
+			assert.NoError(t, err)
+			assert.NoError(t, read.Verify(path))
+
+			// a signature claiming another signer address fails
+			forged := *read
+			forged.Signer = "0x" + hex.EncodeToString(make([]byte, 20))
+			assert.Error(t, forged.Verify(path))
+		}
+
+		acc.Lock()
+		_, err = Sign(path, SHA256, acc, sign)
+		assert.Error(t, err, "locked accounts cannot sign")
+	}
+
+	sig, err := Read(path + Ext)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, []byte("release 1.0 with a backdoor"), 0600))
+	assert.Error(t, sig.Verify(path))
+
+	sig.Digest = hex.EncodeToString(make([]byte, 32))
+	assert.Error(t, sig.Verify(path))
+}
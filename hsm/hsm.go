@@ -0,0 +1,180 @@
+// Package hsm signs with ed25519 keys kept in PKCS#11 tokens such as hardware security modules. Keys are referenced
+// by token label and key ID. Tokens sign with CKM_EDDSA, producing standard RFC 8032 signatures, see
+// accounts.HSMScheme.
+package hsm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/signer"
+)
+
+const edPublicKeySize = 32
+
+// session is a logged in session of a token.
+type session interface {
+	// publicKey returns the ed25519 public key of the key pair with CKA_ID id.
+	publicKey(id []byte) ([]byte, error)
+	// sign signs msg with the ed25519 private key with CKA_ID id.
+	sign(id, msg []byte) ([]byte, error)
+	close()
+}
+
+// openSession opens a session of the token labelled token of PKCS#11 module and logs in with pin.
+var openSession = openPKCS11Session
+
+// PINFunc returns the user PIN of the token labelled token.
+type PINFunc func(token string) []byte
+
+type entry struct {
+	name     string
+	key      accounts.HSMKey
+	pub      []byte
+	verified bool // the token key matches pub
+}
+
+// Signer signs with keys kept in tokens. It logs in to each token once, asking for the token PIN on first use.
+type Signer struct {
+	pin PINFunc
+
+	mu       sync.Mutex
+	keys     map[string]*entry  // by hex public key
+	sessions map[string]session // by module and token label
+}
+
+// NewSigner returns a signer asking pin for the PIN of tokens.
+func NewSigner(pin PINFunc) *Signer {
+	return &Signer{pin: pin, keys: make(map[string]*entry), sessions: make(map[string]session)}
+}
+
+// Add makes the token key of account name with public key pub available for signing.
+func (s *Signer) Add(name string, key accounts.HSMKey, pub []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := hex.EncodeToString(pub)
+	if e, ok := s.keys[id]; ok && e.key == key {
+		e.name = name
+		return
+	}
+	s.keys[id] = &entry{name: name, key: key, pub: pub}
+}
+
+// PublicKeys returns the added keys.
+func (s *Signer) PublicKeys() ([]signer.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]signer.Key, 0, len(s.keys))
+	for id, e := range s.keys {
+		keys = append(keys, signer.Key{Name: e.name, Scheme: accounts.HSMScheme, PubKey: id})
+	}
+	return keys, nil
+}
+
+// Sign signs msg with the token key of public key pub. The token key is checked to match pub before its first use.
+func (s *Signer) Sign(pub, msg []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.keys[hex.EncodeToString(pub)]
+	if !ok {
+		return nil, fmt.Errorf("no token key for public key %x", pub)
+	}
+	id, err := keyID(e.key)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := s.session(e.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.verified {
+		tokenPub, err := sess.publicKey(id)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(tokenPub, e.pub) {
+			return nil, fmt.Errorf("%s does not match the public key of account `%s`", e.key, e.name)
+		}
+		e.verified = true
+	}
+	return sess.sign(id, msg)
+}
+
+// PublicKey reads the public key of key from its token.
+func (s *Signer) PublicKey(key accounts.HSMKey) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := keyID(key)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := s.session(key)
+	if err != nil {
+		return nil, err
+	}
+	return sess.publicKey(id)
+}
+
+// Close logs out of all tokens. Later signing asks for the PINs again.
+func (s *Signer) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, sess := range s.sessions {
+		sess.close()
+		delete(s.sessions, name)
+	}
+	for _, e := range s.keys {
+		e.verified = false
+	}
+}
+
+// session returns the session of the token of key, logging in if needed. Called with mu held.
+func (s *Signer) session(key accounts.HSMKey) (session, error) {
+	name := key.Module + "\x00" + key.Token
+	if sess, ok := s.sessions[name]; ok {
+		return sess, nil
+	}
+	if s.pin == nil {
+		return nil, fmt.Errorf("no PIN entry for token `%s`", key.Token)
+	}
+
+	pin := s.pin(key.Token)
+	defer crypto.Wipe(pin)
+	sess, err := openSession(key.Module, key.Token, pin)
+	if err != nil {
+		return nil, err
+	}
+	s.sessions[name] = sess
+	return sess, nil
+}
+
+func keyID(key accounts.HSMKey) ([]byte, error) {
+	id, err := hex.DecodeString(key.KeyID)
+	if err != nil || len(id) == 0 {
+		return nil, fmt.Errorf("invalid key ID `%s`", key.KeyID)
+	}
+	return id, nil
+}
+
+// decodeECPoint returns the ed25519 public key of a CKA_EC_POINT value, either DER encoded as an OCTET STRING as
+// required by PKCS#11 3.0 or raw as returned by some tokens.
+func decodeECPoint(point []byte) ([]byte, error) {
+	switch {
+	case len(point) == edPublicKeySize:
+		return point, nil
+	case len(point) == edPublicKeySize+2 && point[0] == 0x04 && point[1] == edPublicKeySize:
+		return point[2:], nil
+	default:
+		return nil, fmt.Errorf("unexpected ed25519 public key encoding of %d bytes", len(point))
+	}
+}
@@ -0,0 +1,136 @@
+package hsm
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeToken holds ed25519 keys by hex key ID.
+type fakeToken struct {
+	pin    string
+	keys   map[string]ed25519.PrivateKey
+	logins int
+	closed bool
+}
+
+func (t *fakeToken) publicKey(id []byte) ([]byte, error) {
+	priv, ok := t.keys[hex.EncodeToString(id)]
+	if !ok {
+		return nil, errors.New("no key")
+	}
+	return priv.Public().(ed25519.PublicKey), nil
+}
+
+func (t *fakeToken) sign(id, msg []byte) ([]byte, error) {
+	priv, ok := t.keys[hex.EncodeToString(id)]
+	if !ok {
+		return nil, errors.New("no key")
+	}
+	return ed25519.Sign(priv, msg), nil
+}
+
+func (t *fakeToken) close() {
+	t.closed = true
+}
+
+func useFakeToken(token *fakeToken) func() {
+	open := openSession
+	openSession = func(module, label string, pin []byte) (session, error) {
+		if string(pin) != token.pin {
+			return nil, errors.New("wrong PIN")
+		}
+		token.logins++
+		token.closed = false
+		return token, nil
+	}
+	return func() { openSession = open }
+}
+
+func TestSigner(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	token := &fakeToken{pin: "1234", keys: map[string]ed25519.PrivateKey{"01": priv}}
+	defer useFakeToken(token)()
+
+	pins := 0
+	s := NewSigner(func(label string) []byte {
+		pins++
+		assert.Equal(t, "ops", label)
+		return []byte("1234")
+	})
+	key := accounts.HSMKey{Module: "softhsm", Token: "ops", KeyID: "01"}
+
+	read, err := s.PublicKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(pub), read)
+
+	s.Add("treasury", key, pub)
+	keys, err := s.PublicKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, "treasury", keys[0].Name)
+	assert.Equal(t, accounts.HSMScheme, keys[0].Scheme)
+
+	msg := []byte("libonomy")
+	for i := 0; i < 2; i++ {
+		sig, err := s.Sign(pub, msg)
+		assert.NoError(t, err)
+		assert.True(t, ed25519.Verify(pub, msg, sig))
+	}
+	assert.Equal(t, 1, pins, "the PIN is asked once per token")
+	assert.Equal(t, 1, token.logins)
+
+	_, err = s.Sign(other, msg)
+	assert.Error(t, err, "unknown key")
+
+	// an account whose public key does not match the token key
+	s.Add("forged", accounts.HSMKey{Module: "softhsm", Token: "ops", KeyID: "01"}, other)
+	_, err = s.Sign(other, msg)
+	assert.Error(t, err)
+
+	s.Close()
+	assert.True(t, token.closed)
+	_, err = s.Sign(pub, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pins, "the PIN is asked again after Close")
+}
+
+func TestSignerWrongPIN(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	token := &fakeToken{pin: "1234", keys: map[string]ed25519.PrivateKey{"01": priv}}
+	defer useFakeToken(token)()
+
+	s := NewSigner(func(string) []byte { return []byte("0000") })
+	s.Add("treasury", accounts.HSMKey{Module: "softhsm", Token: "ops", KeyID: "01"}, pub)
+	_, err = s.Sign(pub, []byte("libonomy"))
+	assert.Error(t, err)
+
+	_, err = NewSigner(nil).PublicKey(accounts.HSMKey{Module: "softhsm", Token: "ops", KeyID: "01"})
+	assert.Error(t, err, "no PIN entry")
+	_, err = s.PublicKey(accounts.HSMKey{Module: "softhsm", Token: "ops", KeyID: "xyz"})
+	assert.Error(t, err, "invalid key ID")
+}
+
+func TestDecodeECPoint(t *testing.T) {
+	raw := make([]byte, 32)
+	raw[0] = 7
+	pub, err := decodeECPoint(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, pub)
+
+	pub, err = decodeECPoint(append([]byte{0x04, 0x20}, raw...))
+	assert.NoError(t, err)
+	assert.Equal(t, raw, pub)
+
+	_, err = decodeECPoint(append([]byte{0x03, 0x20}, raw...))
+	assert.Error(t, err)
+}
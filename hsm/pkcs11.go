@@ -0,0 +1,168 @@
+//go:build cgo
+// +build cgo
+
+package hsm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// PKCS#11 3.0 constants missing from the pkcs11 package.
+const (
+	ckkECEdwards = 0x00000040 // CKK_EC_EDWARDS
+	ckmEdDSA     = 0x00001057 // CKM_EDDSA
+)
+
+// module is a loaded PKCS#11 library, shared by the sessions of its tokens.
+type module struct {
+	ctx  *pkcs11.Ctx
+	refs int
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = make(map[string]*module)
+)
+
+func loadModule(path string) (*pkcs11.Ctx, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	if m, ok := modules[path]; ok {
+		m.refs++
+		return m.ctx, nil
+	}
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("cannot load PKCS#11 module %s", path)
+	}
+	if err := ctx.Initialize(); err != nil && !isError(err, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("cannot initialize PKCS#11 module %s: %v", path, err)
+	}
+	modules[path] = &module{ctx: ctx, refs: 1}
+	return ctx, nil
+}
+
+func releaseModule(path string) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	m, ok := modules[path]
+	if !ok {
+		return
+	}
+	if m.refs--; m.refs == 0 {
+		_ = m.ctx.Finalize()
+		m.ctx.Destroy()
+		delete(modules, path)
+	}
+}
+
+func isError(err error, code uint) bool {
+	e, ok := err.(pkcs11.Error)
+	return ok && uint(e) == code
+}
+
+type pkcs11Session struct {
+	module string
+	ctx    *pkcs11.Ctx
+	handle pkcs11.SessionHandle
+}
+
+func openPKCS11Session(modulePath, token string, pin []byte) (session, error) {
+	ctx, err := loadModule(modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	slot, err := findSlot(ctx, token)
+	if err != nil {
+		releaseModule(modulePath)
+		return nil, err
+	}
+	handle, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		releaseModule(modulePath)
+		return nil, fmt.Errorf("cannot open a session of token `%s`: %v", token, err)
+	}
+	if err := ctx.Login(handle, pkcs11.CKU_USER, string(pin)); err != nil && !isError(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		_ = ctx.CloseSession(handle)
+		releaseModule(modulePath)
+		return nil, fmt.Errorf("cannot log in to token `%s`: %v", token, err)
+	}
+	return &pkcs11Session{module: modulePath, ctx: ctx, handle: handle}, nil
+}
+
+// findSlot returns the slot of the token labelled label.
+func findSlot(ctx *pkcs11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err == nil && strings.TrimSpace(info.Label) == label {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("token `%s` not found", label)
+}
+
+// findKey returns the single ed25519 key object of class with CKA_ID id.
+func (s *pkcs11Session) findKey(class uint, id []byte) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECEdwards),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	}
+	if err := s.ctx.FindObjectsInit(s.handle, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := s.ctx.FindObjects(s.handle, 2)
+	_ = s.ctx.FindObjectsFinal(s.handle)
+	if err != nil {
+		return 0, err
+	}
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("no ed25519 key with ID %x", id)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("several ed25519 keys with ID %x", id)
+	}
+}
+
+func (s *pkcs11Session) publicKey(id []byte) ([]byte, error) {
+	obj, err := s.findKey(pkcs11.CKO_PUBLIC_KEY, id)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := s.ctx.GetAttributeValue(s.handle, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+	if err != nil {
+		return nil, err
+	}
+	return decodeECPoint(attrs[0].Value)
+}
+
+func (s *pkcs11Session) sign(id, msg []byte) ([]byte, error) {
+	obj, err := s.findKey(pkcs11.CKO_PRIVATE_KEY, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ctx.SignInit(s.handle, []*pkcs11.Mechanism{pkcs11.NewMechanism(ckmEdDSA, nil)}, obj); err != nil {
+		return nil, err
+	}
+	return s.ctx.Sign(s.handle, msg)
+}
+
+func (s *pkcs11Session) close() {
+	_ = s.ctx.Logout(s.handle)
+	_ = s.ctx.CloseSession(s.handle)
+	releaseModule(s.module)
+}
@@ -0,0 +1,10 @@
+//go:build !cgo
+// +build !cgo
+
+package hsm
+
+import "errors"
+
+func openPKCS11Session(module, token string, pin []byte) (session, error) {
+	return nil, errors.New("PKCS#11 tokens are not supported, the wallet was built without cgo")
+}
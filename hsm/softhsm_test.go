@@ -0,0 +1,105 @@
+//go:build cgo
+// +build cgo
+
+package hsm
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+)
+
+// ed25519Params is the DER encoded OID of Ed25519 (1.3.101.112), the CKA_EC_PARAMS of ed25519 keys.
+var ed25519Params = []byte{0x06, 0x03, 0x2b, 0x65, 0x70}
+
+const ckmECEdwardsKeyPairGen = 0x00001055 // CKM_EC_EDWARDS_KEY_PAIR_GEN
+
+// TestSoftHSM signs with a key generated in a SoftHSM token. It runs when HSM_TEST_MODULE is set, e.g.:
+//
+//	softhsm2-util --init-token --free --label wallet-test --pin 1234 --so-pin 5678
+//	HSM_TEST_MODULE=/usr/lib/softhsm/libsofthsm2.so HSM_TEST_TOKEN=wallet-test HSM_TEST_PIN=1234 go test ./hsm
+func TestSoftHSM(t *testing.T) {
+	module := os.Getenv("HSM_TEST_MODULE")
+	if module == "" {
+		t.Skip("HSM_TEST_MODULE not set")
+	}
+	token, pin := os.Getenv("HSM_TEST_TOKEN"), os.Getenv("HSM_TEST_PIN")
+
+	id, err := crypto.GetRandomBytes(8)
+	assert.NoError(t, err)
+	generateKey(t, module, token, pin, id)
+	defer destroyKey(t, module, token, pin, id)
+
+	s := NewSigner(func(string) []byte { return []byte(pin) })
+	defer s.Close()
+	key := accounts.HSMKey{Module: module, Token: token, KeyID: hex.EncodeToString(id)}
+	pub, err := s.PublicKey(key)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s.Add("softhsm", key, pub)
+	msg := []byte("libonomy")
+	sig, err := s.Sign(pub, msg)
+	assert.NoError(t, err)
+	assert.True(t, ed25519.Verify(pub, msg, sig))
+
+	scheme, err := accounts.GetScheme(accounts.HSMScheme)
+	assert.NoError(t, err)
+	assert.True(t, scheme.Verify(pub, msg, sig))
+}
+
+// destroyKey removes the key objects with CKA_ID id from the token.
+func destroyKey(t *testing.T, module, token, pin string, id []byte) {
+	sess, err := openPKCS11Session(module, token, []byte(pin))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sess.close()
+	s := sess.(*pkcs11Session)
+
+	assert.NoError(t, s.ctx.FindObjectsInit(s.handle, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, id)}))
+	objects, _, err := s.ctx.FindObjects(s.handle, 10)
+	assert.NoError(t, err)
+	assert.NoError(t, s.ctx.FindObjectsFinal(s.handle))
+	for _, obj := range objects {
+		assert.NoError(t, s.ctx.DestroyObject(s.handle, obj))
+	}
+}
+
+// generateKey generates an ed25519 key pair with CKA_ID id stored in the token.
+func generateKey(t *testing.T, module, token, pin string, id []byte) {
+	sess, err := openPKCS11Session(module, token, []byte(pin))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer sess.close()
+	s := sess.(*pkcs11Session)
+
+	_, _, err = s.ctx.GenerateKeyPair(s.handle,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(ckmECEdwardsKeyPairGen, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECEdwards),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, ed25519Params),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECEdwards),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+}
@@ -4,45 +4,76 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"syscall"
 
 	"github.com/libonomy/wallet-cli/client"
 	"github.com/libonomy/wallet-cli/repl"
-	"github.com/libonomy/wallet-cli/wallet/accounts"
+	"github.com/libonomy/wallet-cli/signer"
 )
 
-type mockClient struct {
-}
-
-func (m mockClient) LocalAccount() *accounts.Account {
-	return nil
-}
-
-func (m mockClient) AccountInfo(id string) {
-
-}
-func (m mockClient) Transfer(from, to, amount, passphrase string) error {
-	return nil
-}
-
 func main() {
 	serverHostPort := client.DefaultNodeHostPort
 	datadir := Getwd()
+	account := ""
+	wallet := ""
+	signerSocket := ""
 
 	flag.StringVar(&serverHostPort, "server", serverHostPort, "host:port of the libonomy node HTTP server")
 	flag.StringVar(&datadir, "datadir", datadir, "The directory to store the wallet data within")
+	flag.StringVar(&account, "account", account, "Account to use: index, alias, alias prefix or address")
+	flag.StringVar(&wallet, "wallet", wallet, "Name of the wallet to open instead of the default wallet")
+	flag.StringVar(&signerSocket, "signer", signerSocket, "Unix socket of a signer daemon to sign with instead of unlocking keys, e.g. <datadir>/signer/signer.sock")
+	flag.Float64Var(&repl.MinPassphraseEntropy, "min-passphrase-bits", repl.MinPassphraseEntropy, "Minimal estimated entropy in bits of new passphrases")
 	flag.Parse()
 
-	_, err := syscall.Open("/dev/tty", syscall.O_RDONLY, 0)
+	// the node configured for the wallet is used unless --server is given
+	server := ""
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "server" {
+			server = serverHostPort
+		}
+	})
+
+	be, err := client.NewWalletBE(server, datadir, wallet, repl.WalletPassphrase)
 	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	be.SetNewWalletPassphrase(repl.NewWalletPassphrase)
+	be.SetTokenPIN(repl.TokenPIN)
+
+	// signer daemon mode, serve the unlocked keys on a Unix socket until interrupted
+	if flag.Arg(0) == "signer" {
+		signerFlags := flag.NewFlagSet("signer", flag.ExitOnError)
+		socket := signerFlags.String("socket", signer.DefaultSocketPath(datadir), "Path of the Unix socket to listen on")
+		_ = signerFlags.Parse(flag.Args()[1:])
+		if err := repl.ServeSigner(be, *socket, signerFlags.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if signerSocket != "" {
+		be.SetSigner(signer.NewClient(signerSocket))
+	}
+
+	// non-interactive mode, run the command given as arguments and exit
+	if flag.NArg() > 0 {
+		if err := repl.Exec(be, account, flag.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		return
 	}
-	be, err := client.NewWalletBE(serverHostPort, datadir)
+
+	_, err = syscall.Open("/dev/tty", syscall.O_RDONLY, 0)
 	if err != nil {
 		return
 	}
-	repl.Start(be)
+	repl.Start(be, account)
 }
 
 func Getwd() string {
@@ -0,0 +1,22 @@
+package crypto
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// DefaultArgon2idParams are the argon2id params recommended by RFC 9106 for memory constrained environments.
+var DefaultArgon2idParams = KDParams{Algorithm: KDFArgon2id, Time: 3, Memory: 64 * 1024, Threads: 4, SaltLen: 16, DKLen: 32}
+
+func argon2idKey(password, salt []byte, p KDParams) ([]byte, error) {
+	if p.Time < 1 || p.Threads < 1 || p.DKLen < 1 {
+		return nil, errors.New("invalid argon2id params")
+	}
+	// argon2 needs at least 8 KiB per lane
+	if p.Memory < 8*uint32(p.Threads) {
+		return nil, errors.New("invalid argon2id memory param")
+	}
+
+	return argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, uint32(p.DKLen)), nil
+}
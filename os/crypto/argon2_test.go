@@ -0,0 +1,71 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveKeyArgon2id(t *testing.T) {
+	p := KDParams{Algorithm: KDFArgon2id, Time: 1, Memory: 1024, Threads: 1, SaltLen: 16, DKLen: 32}
+	salt, err := GetRandomBytes(p.SaltLen)
+	assert.NoError(t, err)
+	p.Salt = hex.EncodeToString(salt)
+
+	key, err := DeriveKey([]byte("beagles"), p)
+	assert.NoError(t, err)
+	assert.Len(t, key, p.DKLen)
+
+	again, err := DeriveKey([]byte("beagles"), p)
+	assert.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	other, err := DeriveKey([]byte("poodles"), p)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	bad := p
+	bad.Memory = 4
+	_, err = DeriveKey([]byte("beagles"), bad)
+	assert.Error(t, err)
+
+	bad = p
+	bad.Algorithm = "md5"
+	_, err = DeriveKey([]byte("beagles"), bad)
+	assert.Error(t, err)
+}
+
+func TestKDParamsBackwardCompatible(t *testing.T) {
+	// params stored before the algorithm field was added
+	var p KDParams
+	assert.NoError(t, json.Unmarshal([]byte(`{"n":1024,"r":8,"p":1,"saltLen":4,"dkLen":32,"salt":"abcd0123"}`), &p))
+	assert.Equal(t, KDFScrypt, p.KDFName())
+	_, err := DeriveKey([]byte("beagles"), p)
+	assert.NoError(t, err)
+
+	// and scrypt params are stored as before
+	data, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"n":1024,"r":8,"p":1,"saltLen":4,"dkLen":32,"salt":"abcd0123"}`, string(data))
+}
+
+func TestCalibrate(t *testing.T) {
+	for _, algorithm := range []string{KDFScrypt, KDFArgon2id} {
+		p, err := Calibrate(algorithm, 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Equal(t, algorithm, p.KDFName())
+		assert.Empty(t, p.Salt, "calibrated params should not carry the benchmark salt")
+
+		salt, err := GetRandomBytes(p.SaltLen)
+		assert.NoError(t, err)
+		p.Salt = hex.EncodeToString(salt)
+		_, err = DeriveKey([]byte("beagles"), p)
+		assert.NoError(t, err)
+	}
+
+	_, err := Calibrate("md5", time.Second)
+	assert.Error(t, err)
+}
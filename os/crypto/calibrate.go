@@ -0,0 +1,87 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+const (
+	minScryptN      = 1 << 12
+	maxScryptN      = 1 << 20 // 1 GiB of memory with r = 8
+	minArgon2Memory = 8 * 1024
+	maxArgon2Time   = 100
+)
+
+// Calibrate benchmarks the key derivation algorithm on the local machine and returns the params with the highest
+// cost whose derivation takes at most target. Parameters never go below minimal cost levels, so the derivation can
+// take longer than target on slow machines.
+func Calibrate(algorithm string, target time.Duration) (KDParams, error) {
+	salt, err := GetRandomBytes(DefaultCypherParams.SaltLen)
+	if err != nil {
+		return KDParams{}, err
+	}
+
+	switch algorithm {
+	case KDFScrypt, "":
+		p := KDParams{N: minScryptN, R: 8, P: 1, SaltLen: len(salt), DKLen: 32, Salt: hex.EncodeToString(salt)}
+		// scrypt time is linear in N, double it while the doubled cost still fits the target
+		for p.N < maxScryptN {
+			d, err := benchmark(p)
+			if err != nil {
+				return KDParams{}, err
+			}
+			if 2*d > target {
+				break
+			}
+			p.N *= 2
+		}
+		p.Salt = ""
+		return p, nil
+
+	case KDFArgon2id:
+		threads := runtime.NumCPU()
+		if threads > int(DefaultArgon2idParams.Threads) {
+			threads = int(DefaultArgon2idParams.Threads)
+		}
+		p := KDParams{Algorithm: KDFArgon2id, Time: 1, Memory: DefaultArgon2idParams.Memory, Threads: uint8(threads),
+			SaltLen: len(salt), DKLen: 32, Salt: hex.EncodeToString(salt)}
+
+		// keep the recommended memory unless a single pass is already too slow
+		d, err := benchmark(p)
+		if err != nil {
+			return KDParams{}, err
+		}
+		for d > target && p.Memory/2 >= minArgon2Memory {
+			p.Memory /= 2
+			if d, err = benchmark(p); err != nil {
+				return KDParams{}, err
+			}
+		}
+
+		// argon2 time is linear in the number of passes
+		if passes := uint32(target / d); passes > 1 {
+			p.Time = passes
+		}
+		if p.Time > maxArgon2Time {
+			p.Time = maxArgon2Time
+		}
+		p.Salt = ""
+		return p, nil
+
+	default:
+		return KDParams{}, fmt.Errorf("unsupported key derivation algorithm %s", algorithm)
+	}
+}
+
+// benchmark returns the time taken to derive a key with p.
+func benchmark(p KDParams) (time.Duration, error) {
+	start := time.Now()
+	key, err := DeriveKey([]byte("calibration"), p)
+	if err != nil {
+		return 0, err
+	}
+	Wipe(key)
+	return time.Since(start), nil
+}
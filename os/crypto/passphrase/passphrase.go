@@ -0,0 +1,154 @@
+// Package passphrase generates random diceware-style passphrases and estimates the strength of user-chosen ones.
+package passphrase
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/crypto/wordlist"
+)
+
+const (
+	// DefaultWords is the number of words of a generated passphrase, 80 bits of entropy.
+	DefaultWords = 10
+
+	// DefaultMinEntropy is the minimal estimated entropy in bits of an accepted passphrase.
+	DefaultMinEntropy = 50
+
+	separator = " "
+)
+
+// bitsPerWord is the entropy of a word picked uniformly from the wordlist.
+var bitsPerWord = math.Log2(float64(len(wordlist.Words)))
+
+// commonPassphrases lists passphrases found at the top of leaked password lists. They are rejected whatever their
+// length or character classes.
+var commonPassphrases = map[string]bool{
+	"123456": true, "123456789": true, "12345678": true, "1234567890": true, "password": true, "password1": true,
+	"password123": true, "qwerty": true, "qwerty123": true, "qwertyuiop": true, "abc123": true, "111111": true,
+	"123123": true, "iloveyou": true, "admin": true, "welcome": true, "letmein": true, "monkey": true,
+	"dragon": true, "football": true, "baseball": true, "sunshine": true, "princess": true, "trustno1": true,
+	"passw0rd": true, "p@ssw0rd": true, "p@ssword": true, "master": true, "shadow": true, "superman": true,
+	"changeme": true, "secret": true, "bitcoin": true, "libonomy": true,
+}
+
+// Generate returns a passphrase of words picked uniformly at random from the wordlist and separated by spaces.
+// Callers should wipe the returned bytes after use.
+func Generate(words int) ([]byte, error) {
+	if words < 1 {
+		return nil, fmt.Errorf("invalid number of words %d", words)
+	}
+
+	// the wordlist holds 256 words, so every random byte picks a word without bias
+	idx, err := crypto.GetRandomBytes(words)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.Wipe(idx)
+
+	size := 0
+	for _, b := range idx {
+		size += len(wordlist.Words[b]) + len(separator)
+	}
+	p := make([]byte, 0, size)
+	for i, b := range idx {
+		if i > 0 {
+			p = append(p, separator...)
+		}
+		p = append(p, wordlist.Words[b]...)
+	}
+	return p, nil
+}
+
+// Entropy estimates the entropy in bits of passphrase p. Passphrases made of wordlist words are rated by word count,
+// other passphrases by their length and character classes, with repeated and sequential characters counting little.
+func Entropy(p []byte) float64 {
+	s := string(p)
+	if commonPassphrases[strings.ToLower(s)] {
+		return 0
+	}
+	if bits, ok := wordsEntropy(s); ok {
+		return bits
+	}
+
+	runes := []rune(s)
+	charBits := math.Log2(float64(poolSize(runes)))
+	seen := make(map[rune]bool, len(runes))
+	bits := 0.0
+	for i, c := range runes {
+		switch {
+		case i > 0 && (c == runes[i-1] || c == runes[i-1]+1 || c == runes[i-1]-1):
+			// runs such as "aaaa" or "1234" are guessed early
+			bits++
+		case seen[c]:
+			bits += charBits / 2
+		default:
+			bits += charBits
+		}
+		seen[c] = true
+	}
+	return bits
+}
+
+// wordsEntropy rates s as a sequence of at least two wordlist words. Repeated words add no entropy.
+func wordsEntropy(s string) (float64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '_' || r == '.'
+	})
+	if len(words) < 2 {
+		return 0, false
+	}
+
+	unique := make(map[string]bool, len(words))
+	for _, w := range words {
+		if b, ok := wordlist.Lookup(w); !ok || wordlist.Words[b] != w {
+			return 0, false
+		}
+		unique[w] = true
+	}
+	return float64(len(unique)) * bitsPerWord, true
+}
+
+// poolSize returns the size of the character set an attacker has to try for the character classes of runes.
+func poolSize(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, c := range runes {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= '0' && c <= '9':
+			digit = true
+		case c < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	size := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			size += class.size
+		}
+	}
+	if size < 2 {
+		size = 2
+	}
+	return size
+}
+
+// Check returns an error if the estimated entropy of p is below minBits.
+func Check(p []byte, minBits float64) error {
+	if bits := Entropy(p); bits < minBits {
+		return fmt.Errorf("passphrase too weak: estimated %.0f bits of entropy, at least %.0f required", bits, minBits)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package passphrase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/os/crypto/wordlist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	p, err := Generate(DefaultWords)
+	assert.NoError(t, err)
+
+	words := strings.Split(string(p), separator)
+	assert.Len(t, words, DefaultWords)
+	for _, w := range words {
+		_, ok := wordlist.Lookup(w)
+		assert.True(t, ok, w)
+	}
+
+	other, err := Generate(DefaultWords)
+	assert.NoError(t, err)
+	assert.NotEqual(t, p, other)
+
+	_, err = Generate(0)
+	assert.Error(t, err)
+}
+
+func TestGeneratedPassphraseIsStrong(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		p, err := Generate(DefaultWords)
+		assert.NoError(t, err)
+		assert.True(t, Entropy(p) >= DefaultMinEntropy, string(p))
+		assert.NoError(t, Check(p, DefaultMinEntropy))
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	for _, weak := range []string{"", "password", "Password1", "aaaaaaaaaaaaaaaa", "1234567890123456", "abcdefgh", "tiger"} {
+		assert.Error(t, Check([]byte(weak), DefaultMinEntropy), weak)
+	}
+	for _, strong := range []string{"x7#Kq!v9Lw2$Zp", "Tr0ub4dor&3-horse-Staple!", "tiger zebra mango cloud panda orbit eagle"} {
+		assert.NoError(t, Check([]byte(strong), DefaultMinEntropy), strong)
+	}
+
+	// repeated words add nothing
+	assert.Equal(t, Entropy([]byte("tiger zebra")), Entropy([]byte("tiger zebra tiger zebra")))
+	// sequences count less than random characters of the same classes
+	assert.True(t, Entropy([]byte("abcdefgh")) < Entropy([]byte("qmzrxkvt")))
+}
@@ -3,24 +3,58 @@ package crypto
 import (
 	"encoding/hex"
 	"errors"
+	"fmt"
+
 	"golang.org/x/crypto/scrypt"
 )
 
-// KDParams defines key derivation scheme params.
+// Key derivation algorithms.
+const (
+	KDFScrypt   = "scrypt"
+	KDFArgon2id = "argon2id"
+)
+
+// KDParams defines key derivation scheme params. Params stored without an algorithm are scrypt params.
 type KDParams struct {
-	N       int    `json:"n"`
-	R       int    `json:"r"`
-	P       int    `json:"p"`
-	SaltLen int    `json:"saltLen"`
-	DKLen   int    `json:"dkLen"`
-	Salt    string `json:"salt"` // hex encoded
+	Algorithm string `json:"algorithm,omitempty"` // KDFScrypt if empty
+	N         int    `json:"n,omitempty"`         // scrypt cost
+	R         int    `json:"r,omitempty"`         // scrypt block size
+	P         int    `json:"p,omitempty"`         // scrypt parallelization
+	Time      uint32 `json:"time,omitempty"`      // argon2id passes
+	Memory    uint32 `json:"memory,omitempty"`    // argon2id memory in KiB
+	Threads   uint8  `json:"threads,omitempty"`   // argon2id lanes
+	SaltLen   int    `json:"saltLen"`
+	DKLen     int    `json:"dkLen"`
+	Salt      string `json:"salt"` // hex encoded
 }
 
 // DefaultCypherParams used for key derivation by the app.
 var DefaultCypherParams = KDParams{N: 262144, R: 8, P: 1, SaltLen: 16, DKLen: 32}
 
+// KDFName returns the key derivation algorithm of p.
+func (p KDParams) KDFName() string {
+	if p.Algorithm == "" {
+		return KDFScrypt
+	}
+	return p.Algorithm
+}
+
+// String returns a short description of the algorithm and cost params of p.
+func (p KDParams) String() string {
+	if p.KDFName() == KDFArgon2id {
+		return fmt.Sprintf("%s time=%d memory=%dKiB threads=%d", KDFArgon2id, p.Time, p.Memory, p.Threads)
+	}
+	return fmt.Sprintf("%s N=%d r=%d p=%d", KDFScrypt, p.N, p.R, p.P)
+}
+
 // DeriveKeyFromPassword derives a key from password using the provided KDParams params.
 func DeriveKeyFromPassword(password string, p KDParams) ([]byte, error) {
+	return DeriveKey([]byte(password), p)
+}
+
+// DeriveKey derives a key from password using the provided KDParams params.
+// Unlike DeriveKeyFromPassword it lets callers wipe password once the key was derived.
+func DeriveKey(password []byte, p KDParams) ([]byte, error) {
 
 	if len(p.Salt) == 0 {
 		return nil, errors.New("invalid salt length param")
@@ -35,10 +69,12 @@ func DeriveKeyFromPassword(password string, p KDParams) ([]byte, error) {
 		return nil, errors.New("missing salt")
 	}
 
-	dkData, err := scrypt.Key([]byte(password), salt, p.N, p.R, p.P, p.DKLen)
-	if err != nil {
-		return nil, err
+	switch p.KDFName() {
+	case KDFScrypt:
+		return scrypt.Key(password, salt, p.N, p.R, p.P, p.DKLen)
+	case KDFArgon2id:
+		return argon2idKey(password, salt, p)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation algorithm %s", p.Algorithm)
 	}
-
-	return dkData, nil
 }
@@ -0,0 +1,56 @@
+package crypto
+
+import "runtime"
+
+// Secret holds sensitive bytes such as private keys. The bytes are locked in memory where the platform allows it,
+// so they are not swapped to disk, and are overwritten with zeros when the secret is wiped.
+type Secret struct {
+	b      []byte
+	locked bool
+}
+
+// NewSecret moves data into a new secret. data is wiped and should not be used by the caller afterwards.
+func NewSecret(data []byte) *Secret {
+	s := &Secret{b: make([]byte, len(data))}
+	s.locked = lockMemory(s.b)
+	copy(s.b, data)
+	Wipe(data)
+	runtime.SetFinalizer(s, (*Secret).Wipe)
+	return s
+}
+
+// Bytes returns the secret bytes without copying them. Callers must not keep the returned slice after
+// wiping the secret. It returns nil once the secret was wiped.
+func (s *Secret) Bytes() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.b
+}
+
+// IsWiped returns true iff the secret was wiped.
+func (s *Secret) IsWiped() bool {
+	return s == nil || s.b == nil
+}
+
+// Wipe overwrites the secret bytes with zeros and releases the memory lock.
+func (s *Secret) Wipe() {
+	if s == nil || s.b == nil {
+		return
+	}
+	Wipe(s.b)
+	if s.locked {
+		unlockMemory(s.b)
+		s.locked = false
+	}
+	s.b = nil
+}
+
+// Wipe overwrites b with zeros.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	// keep b reachable until it was overwritten
+	runtime.KeepAlive(b)
+}
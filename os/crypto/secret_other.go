@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package crypto
+
+// lockMemory is not supported on this platform, secrets are only wiped.
+func lockMemory(b []byte) bool {
+	return false
+}
+
+func unlockMemory(b []byte) {}
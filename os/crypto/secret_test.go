@@ -0,0 +1,29 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	data := []byte{1, 2, 3, 4}
+	s := NewSecret(data)
+
+	assert.True(t, bytes.Equal(data, make([]byte, 4)), "source data should be wiped")
+	assert.Equal(t, []byte{1, 2, 3, 4}, s.Bytes())
+	assert.False(t, s.IsWiped())
+
+	b := s.Bytes()
+	s.Wipe()
+	assert.True(t, s.IsWiped())
+	assert.Nil(t, s.Bytes())
+	assert.True(t, bytes.Equal(b, make([]byte, 4)), "secret bytes should be wiped")
+
+	// wiping twice or a nil secret is a no-op
+	s.Wipe()
+	var nilSecret *Secret
+	nilSecret.Wipe()
+	assert.True(t, nilSecret.IsWiped())
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package crypto
+
+import "syscall"
+
+// lockMemory prevents b from being swapped to disk. It returns false if the lock failed, e.g. over RLIMIT_MEMLOCK.
+func lockMemory(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	return syscall.Mlock(b) == nil
+}
+
+func unlockMemory(b []byte) {
+	_ = syscall.Munlock(b)
+}
@@ -0,0 +1,118 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8).
+// A secret is split into n shares so that any k of them recover it while fewer reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+// Split divides secret into n shares, any threshold of which can reconstruct the secret.
+// Each share is len(secret)+1 bytes long, its last byte being the share x coordinate.
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("cannot split an empty secret")
+	}
+	if threshold < 2 {
+		return nil, errors.New("threshold must be at least 2")
+	}
+	if n < threshold {
+		return nil, errors.New("number of shares must not be less than the threshold")
+	}
+	if n > 255 {
+		return nil, errors.New("cannot create more than 255 shares")
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	defer wipe(coefficients)
+	for idx, b := range secret {
+		// random polynomial of degree threshold-1 whose value at 0 is the secret byte
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+		coefficients[0] = b
+
+		for _, share := range shares {
+			share[idx] = evaluate(coefficients, share[len(secret)])
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs a secret from shares created by Split. Passing fewer shares than the split threshold
+// returns a wrong secret rather than an error, callers should verify the result.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+
+	size := len(shares[0])
+	if size < 2 {
+		return nil, errors.New("shares are too short")
+	}
+
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]bool)
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, errors.New("all shares must have the same length")
+		}
+		x := share[size-1]
+		if x == 0 || seen[x] {
+			return nil, errors.New("invalid or duplicate share")
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	secret := make([]byte, size-1)
+	ys := make([]byte, len(shares))
+	for idx := range secret {
+		for i, share := range shares {
+			ys[i] = share[idx]
+		}
+		secret[idx] = interpolateAtZero(xs, ys)
+	}
+	wipe(ys)
+
+	return secret, nil
+}
+
+// evaluate returns the value of the polynomial with the given coefficients at x using Horner's method.
+func evaluate(coefficients []byte, x byte) byte {
+	result := coefficients[len(coefficients)-1]
+	for i := len(coefficients) - 2; i >= 0; i-- {
+		result = add(mul(result, x), coefficients[i])
+	}
+	return result
+}
+
+// interpolateAtZero returns the value at 0 of the Lagrange polynomial passing through the points (xs[i], ys[i]).
+func interpolateAtZero(xs, ys []byte) byte {
+	var result byte
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			// in GF(2^8) subtraction is addition, so (0 - x_j) / (x_i - x_j) = x_j / (x_i ^ x_j)
+			basis = mul(basis, div(xs[j], add(xs[i], xs[j])))
+		}
+		result = add(result, mul(ys[i], basis))
+	}
+	return result
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,65 @@
+package shamir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldArithmetic(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			assert.Equal(t, slowMul(byte(a), byte(b)), mul(byte(a), byte(b)))
+			assert.Equal(t, byte(a), div(mul(byte(a), byte(b)), byte(b)))
+		}
+	}
+	assert.Equal(t, byte(0), mul(0, 7))
+	assert.Equal(t, byte(0), div(0, 7))
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("libonomy treasury key")
+
+	shares, err := Split(secret, 5, 3)
+	assert.NoError(t, err)
+	assert.Len(t, shares, 5)
+
+	// every combination of threshold shares recovers the secret
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				recovered, err := Combine([][]byte{shares[i], shares[j], shares[k]})
+				assert.NoError(t, err)
+				assert.Equal(t, secret, recovered)
+			}
+		}
+	}
+
+	recovered, err := Combine(shares)
+	assert.NoError(t, err)
+	assert.Equal(t, secret, recovered)
+
+	recovered, err = Combine(shares[:2])
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, recovered, "less than threshold shares should not recover the secret")
+}
+
+func TestInvalidInput(t *testing.T) {
+	_, err := Split(nil, 3, 2)
+	assert.Error(t, err)
+	_, err = Split([]byte{1}, 3, 1)
+	assert.Error(t, err)
+	_, err = Split([]byte{1}, 2, 3)
+	assert.Error(t, err)
+	_, err = Split([]byte{1}, 256, 3)
+	assert.Error(t, err)
+
+	shares, err := Split([]byte{1, 2, 3}, 3, 2)
+	assert.NoError(t, err)
+	_, err = Combine(shares[:1])
+	assert.Error(t, err)
+	_, err = Combine([][]byte{shares[0], shares[0]})
+	assert.Error(t, err, "duplicate shares")
+	_, err = Combine([][]byte{shares[0], shares[1][:2]})
+	assert.Error(t, err, "different lengths")
+}
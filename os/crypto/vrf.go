@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"golang.org/x/crypto/ed25519"
 )
@@ -15,17 +16,49 @@ func (s *VRFSigner) Sign(message []byte) []byte {
 	return ed25519.Sign(s.privateKey, message)
 }
 
+// Prove returns the VRF output of message along with its proof. The proof is the deterministic signature of
+// message and the output is the SHA-256 hash of the proof.
+func (s *VRFSigner) Prove(message []byte) (output, proof []byte, err error) {
+	if len(s.privateKey) != ed25519.PrivateKeySize {
+		return nil, nil, errors.New("invalid VRF private key length")
+	}
+	proof = s.Sign(message)
+	return VRFOutput(proof), proof, nil
+}
+
 func NewVRFSigner(privateKey []byte) *VRFSigner {
 	return &VRFSigner{privateKey: privateKey}
 }
 
 func ValidateVRF(message, signature, publicKey []byte) error {
-	if !ed25519.Verify(publicKey, message, signature) {
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, message, signature) {
 		return errors.New("VRF validation failed")
 	}
 	return nil
 }
 
+// VerifyVRF validates the VRF proof of message by publicKey and returns the VRF output.
+func VerifyVRF(message, proof, publicKey []byte) ([]byte, error) {
+	if err := ValidateVRF(message, proof, publicKey); err != nil {
+		return nil, err
+	}
+	return VRFOutput(proof), nil
+}
+
+// VRFOutput returns the VRF output of proof.
+func VRFOutput(proof []byte) []byte {
+	output := sha256.Sum256(proof)
+	return output[:]
+}
+
 func GenerateVRFKeys() (publicKey, privateKey []byte, err error) {
 	return ed25519.GenerateKey(rand.Reader)
 }
+
+// VRFPublicKey returns the public key of a VRF private key.
+func VRFPublicKey(privateKey []byte) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid VRF private key length")
+	}
+	return []byte(ed25519.NewKeyFromSeed(privateKey[:ed25519.SeedSize]).Public().(ed25519.PublicKey)), nil
+}
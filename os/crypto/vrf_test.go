@@ -0,0 +1,38 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVRFProveVerify(t *testing.T) {
+	pub, priv, err := GenerateVRFKeys()
+	assert.NoError(t, err)
+	derived, err := VRFPublicKey(priv)
+	assert.NoError(t, err)
+	assert.Equal(t, pub, derived)
+
+	signer := NewVRFSigner(priv)
+	msg := []byte("layer 42 eligibility")
+	output, proof, err := signer.Prove(msg)
+	assert.NoError(t, err)
+	assert.Len(t, output, 32)
+
+	// the output is deterministic
+	again, _, err := signer.Prove(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, output, again)
+
+	verified, err := VerifyVRF(msg, proof, pub)
+	assert.NoError(t, err)
+	assert.Equal(t, output, verified)
+
+	_, err = VerifyVRF([]byte("layer 43 eligibility"), proof, pub)
+	assert.Error(t, err)
+	_, err = VerifyVRF(msg, proof, pub[:31])
+	assert.Error(t, err)
+
+	_, _, err = NewVRFSigner(priv[:32]).Prove(msg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+// Package wordlist maps bytes to English words so binary data such as backup shares can be written down and
+// typed back reliably. Every word has a unique four letter prefix.
+package wordlist
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Words holds one word per byte value.
+var Words = [256]string{
+	"able", "acid", "acre", "actor", "adult", "aerial", "agent", "alarm",
+	"album", "alert", "alley", "amber", "anchor", "angle", "ankle", "apple",
+	"april", "arena", "armor", "arrow", "artist", "atlas", "august", "autumn",
+	"avocado", "bacon", "badge", "bakery", "bamboo", "banana", "banjo", "barrel",
+	"basket", "beaver", "bench", "berry", "bicycle", "bison", "blanket", "blossom",
+	"board", "bonus", "border", "bottle", "bread", "brick", "bridge", "broccoli",
+	"bronze", "brush", "bubble", "bucket", "buffalo", "butter", "cabin", "camera",
+	"canal", "candle", "canoe", "canvas", "carbon", "carpet", "castle", "cattle",
+	"cedar", "cement", "chalk", "cherry", "chess", "chimney", "cinema", "circle",
+	"citrus", "clay", "cliff", "clock", "cloud", "cobalt", "coconut", "coffee",
+	"comet", "copper", "coral", "cotton", "cousin", "coyote", "crater", "cricket",
+	"cube", "cupboard", "curtain", "cycle", "dance", "dawn", "decade", "deer",
+	"delta", "denim", "desert", "dinner", "dolphin", "domain", "donkey", "dragon",
+	"drum", "eagle", "earth", "echo", "eclipse", "elbow", "engine", "equal",
+	"essay", "exotic", "fabric", "falcon", "fence", "ferry", "fiber", "field",
+	"finger", "flame", "flute", "forest", "fossil", "fountain", "fox", "galaxy",
+	"garden", "garlic", "gentle", "giant", "glacier", "globe", "glove", "goat",
+	"gospel", "gravel", "guitar", "hammer", "harbor", "harvest", "hawk", "helmet",
+	"hockey", "honey", "horizon", "hotel", "humble", "iceberg", "igloo", "income",
+	"indigo", "insect", "ivory", "jacket", "jaguar", "jelly", "jewel", "jungle",
+	"kayak", "kettle", "kidney", "kitten", "koala", "lagoon", "lantern", "laptop",
+	"lava", "lemon", "leopard", "letter", "lilac", "linen", "lizard", "lobster",
+	"lunar", "magnet", "mango", "maple", "marble", "meadow", "melon", "mercury",
+	"metal", "midnight", "mirror", "mosaic", "motor", "muffin", "museum", "napkin",
+	"needle", "nephew", "nickel", "noodle", "novel", "oasis", "ocean", "olive",
+	"onion", "orange", "orbit", "orchid", "oxygen", "oyster", "paddle", "palace",
+	"panda", "parrot", "peanut", "pelican", "pepper", "piano", "pigeon", "pillow",
+	"pilot", "planet", "plaza", "pocket", "pony", "potato", "prism", "pumpkin",
+	"puzzle", "quartz", "rabbit", "radar", "raven", "reef", "ribbon", "robot",
+	"rocket", "saddle", "salmon", "satin", "scarf", "shadow", "silver", "sketch",
+	"sparrow", "spider", "summit", "sunset", "tiger", "tomato", "tulip", "tunnel",
+	"turtle", "velvet", "violin", "walnut", "whale", "winter", "yellow", "zebra",
+}
+
+var index = make(map[string]byte, len(Words))
+
+func init() {
+	for i, w := range Words {
+		index[w] = byte(i)
+	}
+}
+
+// Encode returns the words representing data, one word per byte.
+func Encode(data []byte) []string {
+	words := make([]string, len(data))
+	for i, b := range data {
+		words[i] = Words[b]
+	}
+	return words
+}
+
+// Decode returns the bytes represented by words. Words are matched case insensitively, and may be abbreviated to
+// their first four letters.
+func Decode(words []string) ([]byte, error) {
+	data := make([]byte, len(words))
+	for i, w := range words {
+		b, ok := Lookup(w)
+		if !ok {
+			return nil, fmt.Errorf("unknown word `%s` at position %d", w, i+1)
+		}
+		data[i] = b
+	}
+	return data, nil
+}
+
+// Lookup returns the byte value of word w, which may be abbreviated to its first four letters.
+func Lookup(w string) (byte, bool) {
+	w = strings.ToLower(strings.TrimSpace(w))
+	if b, ok := index[w]; ok {
+		return b, true
+	}
+	if len(w) < 4 {
+		return 0, false
+	}
+	for i, word := range Words {
+		if strings.HasPrefix(word, w[:4]) && strings.HasPrefix(word, w) {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
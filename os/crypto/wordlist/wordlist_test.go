@@ -0,0 +1,42 @@
+package wordlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordsAreUnique(t *testing.T) {
+	prefixes := make(map[string]bool)
+	for _, w := range Words {
+		assert.True(t, len(w) >= 3, w)
+		prefix := w
+		if len(prefix) > 4 {
+			prefix = prefix[:4]
+		}
+		assert.False(t, prefixes[prefix], "duplicate prefix %s", prefix)
+		prefixes[prefix] = true
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	decoded, err := Decode(Encode(data))
+	assert.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	decoded, err = Decode([]string{"ABLE", " acid ", "avoc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "able", Words[decoded[0]])
+	assert.Equal(t, "acid", Words[decoded[1]])
+	assert.Equal(t, "avocado", Words[decoded[2]])
+
+	_, err = Decode([]string{"able", "unicorn"})
+	assert.Error(t, err)
+	_, err = Decode([]string{"ab"})
+	assert.Error(t, err, "abbreviations shorter than four letters are ambiguous")
+}
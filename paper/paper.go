@@ -0,0 +1,65 @@
+// Package paper writes printable paper wallets: a plain text sheet holding an account address, its QR code and
+// optionally the encrypted account keystore.
+package paper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/os/filesystem"
+	"github.com/libonomy/wallet-cli/qr"
+	"github.com/skip2/go-qrcode"
+)
+
+// Wallet is the content of a paper wallet.
+type Wallet struct {
+	Alias    string
+	Scheme   string
+	Address  string
+	Keystore []byte // encrypted keystore JSON, omitted from the sheet if empty
+}
+
+// Render returns the printable sheet. QR codes are drawn for a light background.
+func (w *Wallet) Render() (string, error) {
+	addressQR, err := qr.Render(w.Address, qrcode.Medium, false)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("LIBONOMY PAPER WALLET\n")
+	sb.WriteString("=====================\n\n")
+	fmt.Fprintf(&sb, "Alias:    %s\n", w.Alias)
+	fmt.Fprintf(&sb, "Scheme:   %s\n", w.Scheme)
+	fmt.Fprintf(&sb, "Address:  %s\n", w.Address)
+	fmt.Fprintf(&sb, "Printed:  %s\n\n", time.Now().UTC().Format("2006-01-02 15:04 MST"))
+	sb.WriteString("Receive address:\n\n")
+	sb.WriteString(addressQR)
+
+	if len(w.Keystore) > 0 {
+		keystoreQR, err := qr.Render(string(w.Keystore), qrcode.Low, false)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString("\n---------------------------------------------------------------------\n")
+		sb.WriteString("ENCRYPTED KEY - keep private, spending requires the account passphrase\n")
+		sb.WriteString("---------------------------------------------------------------------\n\n")
+		sb.Write(w.Keystore)
+		sb.WriteString("\n\n")
+		sb.WriteString(keystoreQR)
+	}
+
+	return sb.String(), nil
+}
+
+// Write renders the sheet to the file at path, readable by the owner only.
+func (w *Wallet) Write(path string) error {
+	sheet, err := w.Render()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(sheet), filesystem.OwnerReadWrite)
+}
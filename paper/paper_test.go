@@ -0,0 +1,36 @@
+package paper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paper")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w := &Wallet{Alias: "alice", Scheme: "ed25519", Address: "0x1b0ec2da7cd8aa8d2ba1bbda4b0d3b1a0af5a4f0"}
+	sheet, err := w.Render()
+	assert.NoError(t, err)
+	assert.Contains(t, sheet, w.Address)
+	assert.NotContains(t, sheet, "ENCRYPTED KEY")
+
+	w.Keystore = []byte(`{"alias":"alice","crypto":{}}`)
+	path := filepath.Join(dir, "paper.txt")
+	assert.NoError(t, w.Write(path))
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), "ENCRYPTED KEY"))
+	assert.Contains(t, string(data), string(w.Keystore))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
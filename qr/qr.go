@@ -0,0 +1,59 @@
+// Package qr renders QR codes as text using unicode half block characters, two modules per character cell.
+package qr
+
+import (
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	upperHalf = "▀"
+	lowerHalf = "▄"
+	fullBlock = "█"
+	empty     = " "
+)
+
+// Render encodes content as a QR code with the given recovery level. When invert is set dark modules are drawn
+// blank and light modules as blocks, which is what scans on a terminal with a dark background. Paper and other
+// light backgrounds need invert unset.
+func Render(content string, level qrcode.RecoveryLevel, invert bool) (string, error) {
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return "", err
+	}
+
+	bitmap := code.Bitmap() // includes the quiet zone border
+	if invert {
+		for _, row := range bitmap {
+			for i := range row {
+				row[i] = !row[i]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		for x := range bitmap[y] {
+			top := bitmap[y][x]
+			bottom := y+1 < len(bitmap) && bitmap[y+1][x]
+			switch {
+			case top && bottom:
+				sb.WriteString(fullBlock)
+			case top:
+				sb.WriteString(upperHalf)
+			case bottom:
+				sb.WriteString(lowerHalf)
+			default:
+				sb.WriteString(empty)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// Terminal renders content for display on a dark terminal, see Render.
+func Terminal(content string) (string, error) {
+	return Render(content, qrcode.Medium, true)
+}
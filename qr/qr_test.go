@@ -0,0 +1,33 @@
+package qr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	content := "0x1b0ec2da7cd8aa8d2ba1bbda4b0d3b1a0af5a4f0"
+	code, err := qrcode.New(content, qrcode.Medium)
+	assert.NoError(t, err)
+	size := len(code.Bitmap())
+
+	out, err := Render(content, qrcode.Medium, false)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	assert.Len(t, lines, (size+1)/2, "two module rows per line")
+	for _, line := range lines {
+		assert.Equal(t, size, len([]rune(line)), "one module column per character")
+	}
+
+	// the quiet zone is light, so it is blank when not inverted and blocks when inverted
+	assert.Equal(t, strings.Repeat(empty, size), lines[0])
+	inverted, err := Terminal(content)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat(fullBlock, size), strings.Split(inverted, "\n")[0])
+
+	_, err = Render(strings.Repeat("x", 5000), qrcode.Highest, false)
+	assert.Error(t, err, "content too long for a QR code")
+}
@@ -0,0 +1,388 @@
+package repl
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/approval"
+	"github.com/libonomy/wallet-cli/client"
+	"github.com/libonomy/wallet-cli/log"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+func (r *repl) printEnvelope(e *approval.Envelope) {
+	from, _ := e.Sender()
+	digest, err := e.Digest()
+	if err != nil {
+		log.Error("invalid envelope: %v", err)
+		return
+	}
+
+	fmt.Println(printPrefix, "Transaction summary:")
+	fmt.Println(printPrefix, "From:     ", r.describeAddress(from))
+	fmt.Println(printPrefix, "To:       ", r.describeAddress(e.Tx.Recipient))
+	fmt.Println(printPrefix, "Amount:   ", e.Tx.Amount)
+	fmt.Println(printPrefix, "Gas:      ", e.Tx.Price)
+	fmt.Println(printPrefix, "Gas limit:", e.Tx.GasLimit)
+	fmt.Println(printPrefix, "Nonce:    ", e.Tx.AccountNonce)
+	fmt.Println(printPrefix, "Network:  ", e.NetworkID)
+	if e.Memo != "" {
+		fmt.Println(printPrefix, "Memo:     ", e.Memo)
+	}
+	fmt.Println(printPrefix, "Digest:   ", hex.EncodeToString(digest))
+	if network := r.client.WalletMetadata().NetworkID; e.NetworkID != network {
+		fmt.Println(printPrefix, fmt.Sprintf(envelopeNetworkMsg, e.NetworkID, network))
+	}
+}
+
+func (r *repl) createEnvelope() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+	if err := r.client.CheckTransferKey(acc); err != nil {
+		return err
+	}
+
+	info, err := r.client.AccountInfo(hex.EncodeToString(acc.Address().Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to get account info: %v", err)
+	}
+	nonce, err := strconv.ParseUint(info.Nonce, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account nonce: %v", err)
+	}
+
+	dest, err := r.destinationAddress()
+	if err != nil {
+		return err
+	}
+	amountStr, err := inputNotBlank(amountToTransferMsg)
+	if err != nil {
+		return err
+	}
+	amount, err := strconv.ParseUint(amountStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %v", err)
+	}
+	gas, err := r.gasPrice()
+	if err != nil {
+		return err
+	}
+	if err := printFees(info.Balance, amount, gas, defaultGasLimit); err != nil {
+		return err
+	}
+	memo, err := input(envelopeMemoMsg)
+	if err != nil {
+		return err
+	}
+	memo = strings.TrimSpace(memo)
+
+	tx := client.InnerSerializableSignedTransaction{
+		AccountNonce: nonce,
+		Recipient:    dest,
+		GasLimit:     defaultGasLimit,
+		Price:        gas,
+		Amount:       amount,
+	}
+	e := approval.NewEnvelope(r.client.WalletMetadata().NetworkID, acc.Address(), tx, memo)
+
+	path := fmt.Sprintf("%s-%d%s", acc.Name, nonce, approval.EnvelopeExt)
+	if len(r.params) > 0 {
+		path = r.params[0]
+	}
+	if ok, err := confirmOverwrite(path); !ok || err != nil {
+		return err
+	}
+	if err := e.Write(path); err != nil {
+		return fmt.Errorf("failed to write envelope: %v", err)
+	}
+
+	r.printEnvelope(e)
+	fmt.Println(printPrefix, fmt.Sprintf("Envelope written to %s, send it to the approvers.", path))
+	return nil
+}
+
+func (r *repl) signEnvelope() error {
+	if len(r.params) == 0 {
+		return errors.New("usage: approval sign <envelope path>")
+	}
+	envPath := r.params[0]
+	e, err := approval.ReadEnvelope(envPath)
+	if err != nil {
+		return fmt.Errorf("failed to read envelope: %v", err)
+	}
+
+	r.printEnvelope(e)
+	acc, err := r.signingAccount()
+	if err != nil {
+		return err
+	}
+	answer, err := yesOrNoQuestion(fmt.Sprintf(confirmApprovalMsg, acc.Name))
+	if err != nil || answer == "n" {
+		return err
+	}
+
+	a, err := e.Approve(acc, r.client.Sign)
+	if err != nil {
+		return fmt.Errorf("failed to approve: %v", err)
+	}
+	r.client.Audit("approval-sign", acc, map[string]string{"digest": a.Digest})
+	path := strings.TrimSuffix(envPath, approval.EnvelopeExt) + "." + acc.Name + approval.ApprovalExt
+	if ok, err := confirmOverwrite(path); !ok || err != nil {
+		return err
+	}
+	if err := a.Write(path); err != nil {
+		return fmt.Errorf("failed to write approval: %v", err)
+	}
+
+	fmt.Println(printPrefix, fmt.Sprintf("Approval written to %s, send it to the sender of the transaction.", path))
+	return nil
+}
+
+func (r *repl) addApprovals() error {
+	if len(r.params) < 2 {
+		return errors.New("usage: approval add <envelope path> <approval path>...")
+	}
+	envPath := r.params[0]
+	e, err := approval.ReadEnvelope(envPath)
+	if err != nil {
+		return fmt.Errorf("failed to read envelope: %v", err)
+	}
+
+	added := 0
+	for _, path := range r.params[1:] {
+		a, err := approval.ReadApproval(path)
+		if err == nil {
+			err = e.Add(a)
+		}
+		if err != nil {
+			log.Error("rejected approval %s: %v", path, err)
+			continue
+		}
+		fmt.Println(printPrefix, fmt.Sprintf("Added approval by %s", r.describeAddress(address.HexToAddress(a.Approver))))
+		added++
+	}
+	if added == 0 {
+		return errors.New("no approval added")
+	}
+	if err := e.Write(envPath); err != nil {
+		return fmt.Errorf("failed to write envelope: %v", err)
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("Envelope %s holds %d approvals", envPath, len(e.Approvals)))
+	return nil
+}
+
+// readEnvelopeAndPolicy reads the envelope given as the first command param and the approval policy of its sender,
+// stored in the wallet or, if allowed, read from the path given as the second param.
+func (r *repl) readEnvelopeAndPolicy(usage string, policyFile bool) (*approval.Envelope, *approval.Policy, error) {
+	if len(r.params) < 1 {
+		return nil, nil, fmt.Errorf("usage: %s", usage)
+	}
+	e, err := approval.ReadEnvelope(r.params[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read envelope: %v", err)
+	}
+
+	if policyFile && len(r.params) > 1 {
+		p, err := approval.ReadPolicy(r.params[1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read policy: %v", err)
+		}
+		return e, p, nil
+	}
+
+	from, _ := e.Sender()
+	name, ok := r.client.AccountByAddress(from)
+	if !ok {
+		return nil, nil, fmt.Errorf("the sender %s is not an account of this wallet", e.From)
+	}
+	data, err := r.client.ApprovalPolicy(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get approval policy: %v", err)
+	}
+	if data == nil {
+		return nil, nil, fmt.Errorf("account `%s` has no approval policy, see `approval policy set`", name)
+	}
+	p, err := approval.ParsePolicy(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read approval policy: %v", err)
+	}
+	return e, p, nil
+}
+
+func (r *repl) envelopeStatus() error {
+	e, p, err := r.readEnvelopeAndPolicy("approval status <envelope path> [policy path]", true)
+	if err != nil {
+		return err
+	}
+
+	r.printEnvelope(e)
+	approved, err := p.Check(e)
+	fmt.Println(printPrefix, fmt.Sprintf("Approved by: %s", strings.Join(approved, ", ")))
+	if err != nil {
+		fmt.Println(printPrefix, fmt.Sprintf("Not ready to broadcast: %v", err))
+		return nil
+	}
+	fmt.Println(printPrefix, "Ready to broadcast.")
+	return nil
+}
+
+func (r *repl) broadcastEnvelope() error {
+	e, p, err := r.readEnvelopeAndPolicy("approval broadcast <envelope path>", false)
+	if err != nil {
+		return err
+	}
+
+	approved, err := p.Check(e)
+	if err != nil {
+		return fmt.Errorf("the transaction is not approved: %v", err)
+	}
+	if network := r.client.WalletMetadata().NetworkID; e.NetworkID != network {
+		return fmt.Errorf("the transaction is for network %d but the wallet uses network %d", e.NetworkID, network)
+	}
+
+	from, _ := e.Sender()
+	name, _ := r.client.AccountByAddress(from)
+	if acc := r.client.CurrentAccount(); acc == nil || acc.Address() != from {
+		sender, err := r.client.GetAccount(name)
+		if err != nil {
+			return fmt.Errorf("failed to load account: %v", err)
+		}
+		r.client.SetCurrentAccount(sender)
+	}
+
+	info, err := r.client.AccountInfo(hex.EncodeToString(from.Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to get account info: %v", err)
+	}
+	if info.Nonce != strconv.FormatUint(e.Tx.AccountNonce, 10) {
+		return fmt.Errorf("the transaction nonce %d is stale, the account nonce is %s", e.Tx.AccountNonce, info.Nonce)
+	}
+
+	r.printEnvelope(e)
+	if err := printFees(info.Balance, e.Tx.Amount, e.Tx.Price, e.Tx.GasLimit); err != nil {
+		return err
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("Approved by: %s", strings.Join(approved, ", ")))
+	answer, err := yesOrNoQuestion(confirmTransactionMsg)
+	if err != nil || answer == "n" {
+		return err
+	}
+	acc, err := r.signingAccount()
+	if err != nil {
+		return err
+	}
+	if err := r.allowSpending(acc, e.Tx.Recipient, e.Tx.Amount, e.Tx.Price); err != nil {
+		return err
+	}
+
+	id, err := r.client.TransferApproved(e, acc)
+	if err != nil {
+		return err
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("tx submitted, id: %v", id))
+	return nil
+}
+
+func (r *repl) showApprovalPolicy() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	data, err := r.client.ApprovalPolicy(acc.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get approval policy: %v", err)
+	}
+	if data == nil {
+		fmt.Println(printPrefix, fmt.Sprintf("Account `%s` has no approval policy", acc.Name))
+		return nil
+	}
+	p, err := approval.ParsePolicy(data)
+	if err != nil {
+		return fmt.Errorf("failed to read approval policy: %v", err)
+	}
+	r.printApprovalPolicy(p)
+	return nil
+}
+
+func (r *repl) printApprovalPolicy(p *approval.Policy) {
+	fmt.Println(printPrefix, fmt.Sprintf("%d of %d approvals required from:", p.Threshold, len(p.Approvers)))
+	for _, a := range p.Approvers {
+		fmt.Println(printPrefix, " -", a.Name, a.PubKey)
+	}
+}
+
+func (r *repl) setApprovalPolicy() error {
+	if len(r.params) == 0 {
+		return errors.New("usage: approval policy set <policy path>")
+	}
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	p, err := approval.ReadPolicy(r.params[0])
+	if err != nil {
+		return fmt.Errorf("failed to read policy: %v", err)
+	}
+	if p.Account != "" && address.HexToAddress(p.Account) != acc.Address() {
+		return fmt.Errorf("the policy applies to account %s, not to `%s`", p.Account, acc.Name)
+	}
+	p.Account = accounts.StringAddress(acc.Address())
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode policy: %v", err)
+	}
+
+	r.printApprovalPolicy(p)
+	answer, err := yesOrNoQuestion(fmt.Sprintf(approvalPolicyMsg, acc.Name))
+	if err != nil || answer == "n" {
+		return err
+	}
+	return r.storeApprovalPolicy(acc, data)
+}
+
+func (r *repl) removeApprovalPolicy() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+	return r.storeApprovalPolicy(acc, nil)
+}
+
+// storeApprovalPolicy sets the approval policy of acc, asking for the account passphrase to change an existing policy.
+func (r *repl) storeApprovalPolicy(acc *accounts.Account, policy []byte) error {
+	current, err := r.client.ApprovalPolicy(acc.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get approval policy: %v", err)
+	}
+	if current == nil && policy == nil {
+		fmt.Println(printPrefix, fmt.Sprintf("Account `%s` has no approval policy", acc.Name))
+		return nil
+	}
+
+	var passphrase []byte
+	if current != nil {
+		if passphrase, err = inputPassword(accountPassphrase); err != nil {
+			return err
+		}
+		defer crypto.Wipe(passphrase)
+	}
+	if err := r.client.SetApprovalPolicy(acc.Name, policy, passphrase); err != nil {
+		return fmt.Errorf("failed to set approval policy: %v", err)
+	}
+	if policy == nil {
+		fmt.Println(printPrefix, fmt.Sprintf("Transfers of account `%s` no longer require approvals", acc.Name))
+		return nil
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("Transfers of account `%s` now require approvals", acc.Name))
+	return nil
+}
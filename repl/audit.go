@@ -0,0 +1,50 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+func (r *repl) verifyAudit() error {
+	report, err := r.client.VerifyAudit()
+	if os.IsNotExist(err) {
+		fmt.Println(printPrefix, "The audit log is empty.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %v", err)
+	}
+
+	fmt.Println(printPrefix, "Audit log:  ", r.client.AuditLogPath())
+	fmt.Println(printPrefix, "Entries:    ", report.Entries)
+	for _, c := range report.Checkpoints {
+		fmt.Println(printPrefix, fmt.Sprintf("Checkpoint at entry %d on %s signed by %s", c.Seq, c.Time.Local().Format("2006-01-02 15:04:05"), r.describeAddress(address.HexToAddress(c.Account))))
+	}
+	if report.Unsigned > 0 {
+		fmt.Println(printPrefix, fmt.Sprintf("The last %d entries are not covered by a checkpoint, deleting them cannot be detected. Run `audit checkpoint` to sign them.", report.Unsigned))
+	}
+	if report.OK() {
+		fmt.Println(printPrefix, "No deleted or modified entries found.")
+		return nil
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("The audit log was tampered with, %d problems found:", len(report.Problems)))
+	for _, p := range report.Problems {
+		fmt.Println(printPrefix, " -", p)
+	}
+	return errors.New("audit log verification failed")
+}
+
+func (r *repl) auditCheckpoint() error {
+	acc, err := r.signingAccount()
+	if err != nil {
+		return err
+	}
+	if err := r.client.AuditCheckpoint(acc); err != nil {
+		return fmt.Errorf("failed to sign audit log checkpoint: %v", err)
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("Signed the audit log with account `%s`", acc.Name))
+	return nil
+}
@@ -8,7 +8,7 @@ const (
 	initialTransferMsg          = "Transfer coins from local account to another account."
 	transferFromLocalAccountMsg = "Transfer from local account %s ? (y/n) "
 	transferFromAccountMsg      = "Enter or paste account id: "
-	destAddressMsg              = "Enter or paste destination address: "
+	destAddressMsg              = "Enter or paste destination address or contact name: "
 	amountToTransferMsg         = "Enter amount to transfer in Smidge (SMD): "
 	accountPassphrase           = "Enter local account passphrase: "
 	confirmTransactionMsg       = "Confirm transaction (y/n): "
@@ -17,11 +17,84 @@ const (
 	requiresSetupMsg            = "libonomy requires a minimum of 300GB of free disk space. 250GB are used for POST and 50GB are reserved for the global computer state. You may allocate additional disk space for POST in 300GB increments. "
 	restartNodeMsg              = "Restart node?"
 	createAccountMsg            = "Account alias (name): "
-	useDefaultGasMsg            = "Use default gas price (1 Smidge)? (y/n) "
-	enterGasPrice               = "Enter transaction gas price:"
+	gasPriceMsg                 = "Gas price: low, normal, fast or a number (ENTER for normal): "
 	getAccountInfoMsg           = "Enter account id to query"
 	libonomyDatadirMsg          = "Enter data file directory: "
 	libonomySpaceAllocationMsg  = "Enter space allocation (GB): "
 	msgSignMsg                  = "Enter message to sign (in hex): "
 	msgTextSignMsg              = "Enter text message to sign: "
+	msgTextVerifyMsg            = "Enter signed text message: "
+	pubKeyMsg                   = "Enter signer public key (in hex): "
+	signatureMsg                = "Enter signature (in hex): "
+	chooseAccountMsg            = "Enter number, alias, address or filter: "
+	newAccountAliasMsg          = "New account alias (name): "
+	confirmDeleteAccountMsg     = "Type the account alias `%s` to confirm deletion: "
+	backupPassphraseMsg         = "Enter passphrase to encrypt the account backup: "
+	walletPassphraseMsg         = "Enter wallet passphrase: "
+	newWalletPassphraseMsg      = "Choose wallet passphrase: "
+	protectWalletMsg            = "The wallet file is protected by a wallet passphrase, asked whenever the wallet is opened."
+	tamperWarningMsg            = `
+!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!
+!! WARNING: WALLET INTEGRITY CHECK FAILED                                     !!
+!! Either the wallet passphrase is wrong or the wallet file was modified      !!
+!! outside of the wallet. Addresses and aliases may have been swapped.        !!
+!! Do NOT send funds to your own addresses. The wallet is opened read-only.   !!
+!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!
+`
+	legacyWalletMsg = `
+!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!
+!! WARNING: WALLET FILE WITHOUT INTEGRITY TAG                                 !!
+!! The wallet file was upgraded from a format without integrity protection.   !!
+!! If you had set a wallet passphrase, the file was modified outside of the   !!
+!! wallet: restore it from a backup. Otherwise choose a wallet passphrase.    !!
+!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!
+`
+	newAccountPassphraseMsg = "Choose account passphrase: "
+	confirmPassphraseMsg    = "Repeat passphrase: "
+	sharesCountMsg          = "Total number of shares: "
+	sharesThresholdMsg      = "Number of shares required to recover: "
+	enterShareMsg           = "Enter share #%d (hex or words): "
+	vanityPrefixMsg         = "Address prefix, case sensitive (enter hex or ENTER): "
+	vanitySuffixMsg         = "Address suffix, case sensitive (enter hex or ENTER): "
+	startVanityMsg          = "Start generating? (y/n) "
+	contactNameMsg          = "Contact name: "
+	contactNewNameMsg       = "New contact name: "
+	contactAddressMsg       = "Contact address: "
+	contactNoteMsg          = "Note (enter text or ENTER): "
+	unknownDestAddressMsg   = "Warning: destination address is neither in the address book nor one of your accounts."
+	walletNameMsg           = "Wallet name: "
+	walletNodeMsg           = "Node host:port (enter host:port or ENTER for default): "
+	networkIDMsg            = "Network ID: "
+	confirmKDFMsg           = "Use these params for new keys? Existing keys keep their params. (y/n) "
+	recipientKeyMsg         = "Enter recipient account alias or public key (in hex): "
+	msgTextEncryptMsg       = "Enter text message to encrypt: "
+	msgDecryptMsg           = "Enter encrypted message (in hex): "
+	replaceVRFKeyMsg        = "Account `%s` already has a VRF key, replace it? (y/n) "
+	vrfMessageMsg           = "Enter message (in hex, or base64 with --base64): "
+	vrfPubKeyMsg            = "Enter VRF public key (in hex, or base64 with --base64): "
+	vrfProofMsg             = "Enter VRF proof (in hex, or base64 with --base64): "
+	overwriteFileMsg        = "File %s exists, overwrite it? (y/n) "
+	typedDataMsg            = "Paste typed data JSON: "
+	typedDataNetworkMsg     = "Warning: the message is signed for network %d but the wallet uses network %d."
+	confirmSignTypedMsg     = "Sign this message? (y/n) "
+	authChallengeMsg        = "Paste the login challenge: "
+	confirmAuthMsg          = "Log in to %s with account `%s`? (y/n) "
+	hsmNoTransferMsg        = "Token accounts sign messages but cannot send transfers. Add one anyway? (y/n) "
+	hsmModuleMsg            = "PKCS#11 module path: "
+	hsmTokenMsg             = "Token label: "
+	hsmKeyIDMsg             = "Key ID (in hex): "
+	tokenPINMsg             = "Enter PIN of token `%s`: "
+	envelopeMemoMsg         = "Memo for the approvers (enter text or ENTER): "
+	envelopeNetworkMsg      = "Warning: the transaction is for network %d but the wallet uses network %d."
+	confirmApprovalMsg      = "Approve this transaction with account `%s`? (y/n) "
+	approvalPolicyMsg       = "Require these approvals for every transfer of account `%s`? (y/n) "
+	overrideSpendingMsg     = "Override the spending policy for this transfer? It is recorded in the audit log. (y/n) "
+	maxAmountMsg            = "Max amount per transaction, 0 for none (ENTER keeps %d): "
+	dailyCapMsg             = "Daily cap, 0 for none (ENTER keeps %d): "
+	weeklyCapMsg            = "Weekly cap, 0 for none (ENTER keeps %d): "
+	maxGasPriceMsg          = "Max gas price, 0 for none (ENTER keeps %d): "
+	allowListMsg            = "Allowed recipients, any if none, - to clear (ENTER keeps [%s]): "
+	denyListMsg             = "Denied recipients, - to clear (ENTER keeps [%s]): "
+	confirmSpendingMsg      = "Set this spending policy? (y/n) "
+	generatedPassphraseMsg  = "Generated passphrase, write it down and keep it offline. It cannot be recovered:"
 )
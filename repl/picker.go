@@ -0,0 +1,94 @@
+package repl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/libonomy/wallet-cli/accounts"
+)
+
+// maximum number of accounts printed at once by the account picker
+const pickerPageSize = 20
+
+// pickAccount asks the user to choose one of aliases. The user may type an index into the printed list, an alias
+// prefix, an address or a fuzzy pattern. Ambiguous input narrows the list down until a single account is left.
+func (r *repl) pickAccount(aliases []string) (string, error) {
+	if len(aliases) == 0 {
+		return "", nil
+	}
+
+	if err := requireInput(chooseAccountMsg); err != nil {
+		return "", err
+	}
+	candidates := r.client.MatchAccounts(aliases, "")
+	for {
+		r.printAccounts(candidates)
+		input := prompt.Input(prefix+chooseAccountMsg,
+			r.accountCompleter(candidates),
+			prompt.OptionPrefixTextColor(prompt.LightGray))
+
+		s := strings.TrimSpace(input)
+		if s == "quit" || s == "exit" {
+			fmt.Println("Bye!")
+			os.Exit(0)
+			return "", nil
+		}
+		if s == "" {
+			candidates = r.client.MatchAccounts(aliases, "")
+			continue
+		}
+
+		matches := r.client.MatchAccounts(candidates, s)
+		switch len(matches) {
+		case 0:
+			fmt.Println(printPrefix, fmt.Sprintf("no account matches `%s`.", s))
+		case 1:
+			return matches[0], nil
+		default:
+			candidates = matches
+		}
+	}
+}
+
+func (r *repl) printAccounts(aliases []string) {
+	for i, alias := range aliases {
+		if i == pickerPageSize {
+			fmt.Println(printPrefix, fmt.Sprintf("... and %d more, type to filter (ENTER shows all)", len(aliases)-pickerPageSize))
+			return
+		}
+
+		addr, err := r.client.AccountAddress(alias)
+		if err != nil {
+			fmt.Println(printPrefix, fmt.Sprintf("%3d) %s", i+1, alias))
+			continue
+		}
+
+		balance, ok := r.client.CachedBalance(hex.EncodeToString(addr.Bytes()))
+		if !ok {
+			balance = "?"
+		}
+		storage := ""
+		if _, ok := r.client.HSMKey(alias); ok {
+			storage = " (token, no transfers)"
+		}
+		fmt.Println(printPrefix, fmt.Sprintf("%3d) %-20s %s balance: %s%s", i+1, alias, accounts.StringAddress(addr), balance, storage))
+	}
+}
+
+func (r *repl) accountCompleter(aliases []string) prompt.Completer {
+	return func(in prompt.Document) []prompt.Suggest {
+		suggests := make([]prompt.Suggest, 0, len(aliases))
+		for _, alias := range aliases {
+			s := prompt.Suggest{Text: alias}
+			if addr, err := r.client.AccountAddress(alias); err == nil {
+				s.Description = accounts.StringAddress(addr)
+			}
+			suggests = append(suggests, s)
+		}
+
+		return prompt.FilterFuzzy(suggests, in.GetWordBeforeCursor(), true)
+	}
+}
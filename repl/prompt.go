@@ -1,27 +1,47 @@
 package repl
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"syscall"
 
 	"github.com/c-bata/go-prompt"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/crypto/passphrase"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var emptyComplete = func(prompt.Document) []prompt.Suggest { return []prompt.Suggest{} }
 
+// interactive is false while Exec runs a command. Prompts then fail instead of waiting for input on stdin.
+var interactive = true
+
+// errInputRequired is returned, wrapped with the prompt text, by a prompt in non-interactive mode.
+var errInputRequired = errors.New("run it in the prompt to enter it")
+
+// requireInput returns errInputRequired for the prompt msg in non-interactive mode.
+func requireInput(msg string) error {
+	if !interactive {
+		return fmt.Errorf("the command asks `%s`, %w", strings.TrimSpace(msg), errInputRequired)
+	}
+	return nil
+}
+
 func runPrompt(executor func(string), completer func(prompt.Document) []prompt.Suggest,
-	firstTime func(), length uint16) {
+	firstTime func(), livePrefix func() (string, bool), length uint16) {
 	p := prompt.New(
 		executor,
 		completer,
 		prompt.OptionPrefix(prefix),
+		prompt.OptionLivePrefix(livePrefix),
 		prompt.OptionPrefixTextColor(prompt.LightGray),
 		prompt.OptionMaxSuggestion(length),
 		prompt.OptionShowCompletionAtStart(),
 		prompt.OptionAddKeyBind(
-			prompt.KeyBind{prompt.ControlC, func(*prompt.Buffer){
+			prompt.KeyBind{Key: prompt.ControlC, Fn: func(*prompt.Buffer) {
 				_ = syscall.Kill(syscall.Getpid(), syscall.SIGINT)
 			}}),
 	)
@@ -30,7 +50,10 @@ func runPrompt(executor func(string), completer func(prompt.Document) []prompt.S
 }
 
 // executes prompt waiting for an input with y or n
-func yesOrNoQuestion(msg string) string {
+func yesOrNoQuestion(msg string) (string, error) {
+	if err := requireInput(msg); err != nil {
+		return "", err
+	}
 	var input string
 	for {
 		input = prompt.Input(prefix+msg,
@@ -44,56 +67,162 @@ func yesOrNoQuestion(msg string) string {
 		fmt.Println(printPrefix, "invalid command.")
 	}
 
-	return input
+	return input, nil
 }
 
-func multipleChoice(names []string) string {
-	var input string
-	accounts := make(map[string]struct{})
-	for _, name := range names {
-		accounts[name] = struct{}{}
+// executes prompt waiting an input, blank input is allowed
+func input(msg string) (string, error) {
+	if err := requireInput(msg); err != nil {
+		return "", err
 	}
-	if len(accounts) == 0 {
-		return ""
+	return prompt.Input(prefix+msg,
+		emptyComplete,
+		prompt.OptionPrefixTextColor(prompt.LightGray)), nil
+}
+
+// executes prompt waiting an input not blank
+func inputNotBlank(msg string) (string, error) {
+	return inputNotBlankWithCompletion(msg, emptyComplete)
+}
+
+// executes prompt waiting an input not blank, suggesting values using completer
+func inputNotBlankWithCompletion(msg string, completer prompt.Completer) (string, error) {
+	if err := requireInput(msg); err != nil {
+		return "", err
 	}
+	var input string
 	for {
-		for ac := range accounts {
-			fmt.Println(printPrefix, ac)
+		input = prompt.Input(prefix+msg,
+			completer,
+			prompt.OptionPrefixTextColor(prompt.LightGray),
+			// a suggestion replaces the whole input, not only its last word
+			prompt.OptionCompletionWordSeparator("\n"))
+
+		if strings.TrimSpace(input) != "" {
+			break
 		}
-		input = prompt.Input(prefix,
-			emptyComplete,
-			prompt.OptionPrefixTextColor(prompt.LightGray))
 
-		if _, ok := accounts[input]; ok {
-			return input
+		fmt.Println(printPrefix, "please enter a value.")
+	}
+
+	return input, nil
+}
+
+// reads a password from the terminal without echoing it. Callers should wipe the returned bytes after use. In
+// non-interactive mode passwords are still read if stdin is a terminal.
+func inputPassword(msg string) ([]byte, error) {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		if err := requireInput(msg); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		fmt.Print(prefix + msg)
+		password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase: %v", err)
 		}
 
-		s := strings.TrimSpace(input)
-		if s == "quit" || s == "exit" {
-			fmt.Println("Bye!")
-			os.Exit(0)
-			return ""
+		if len(password) != 0 {
+			return password, nil
 		}
 
-		fmt.Println(printPrefix, "invalid command.")
+		fmt.Println(printPrefix, "please enter a value.")
+	}
+}
 
+// reads a new password twice from the terminal until both inputs match
+func inputNewPassword(msg string) ([]byte, error) {
+	for {
+		password, err := inputPassword(msg)
+		if err != nil {
+			return nil, err
+		}
+		confirmation, err := inputPassword(confirmPassphraseMsg)
+		if err != nil {
+			crypto.Wipe(password)
+			return nil, err
+		}
+		match := bytes.Equal(password, confirmation)
+		crypto.Wipe(confirmation)
+		if match {
+			return password, nil
+		}
+
+		crypto.Wipe(password)
+		fmt.Println(printPrefix, "passphrases do not match.")
 	}
 }
 
-// executes prompt waiting an input not blank
-func inputNotBlank(msg string) string {
-	var input string
+// reads a new password until its estimated entropy reaches MinPassphraseEntropy
+func inputStrongPassword(msg string) ([]byte, error) {
 	for {
-		input = prompt.Input(prefix+msg,
-			emptyComplete,
-			prompt.OptionPrefixTextColor(prompt.LightGray))
+		password, err := inputNewPassword(msg)
+		if err != nil {
+			return nil, err
+		}
+		err = passphrase.Check(password, MinPassphraseEntropy)
+		if err == nil {
+			return password, nil
+		}
 
-		if strings.TrimSpace(input) != "" {
-			break
+		crypto.Wipe(password)
+		fmt.Println(printPrefix, err)
+	}
+}
+
+// offers to generate an account password, reading a strong one from the terminal otherwise
+func inputNewAccountPassword() ([]byte, error) {
+	answer, err := yesOrNoQuestion(generateMsg)
+	if err != nil {
+		return nil, err
+	}
+	if answer == "y" {
+		password, err := generatePassword()
+		if err != nil {
+			return nil, err
+		}
+		if password != nil {
+			return password, nil
 		}
+	}
+	return inputStrongPassword(newAccountPassphraseMsg)
+}
 
-		fmt.Println(printPrefix, "please enter a value.")
+// generates a random password and shows it until the user types it back, nil if generation fails
+func generatePassword() ([]byte, error) {
+	password, err := passphrase.Generate(passphrase.DefaultWords)
+	if err != nil {
+		fmt.Println(printPrefix, "failed to generate passphrase:", err)
+		return nil, nil
 	}
 
-	return input
+	fmt.Println(printPrefix, generatedPassphraseMsg)
+	fmt.Print("\n    ")
+	_, _ = os.Stdout.Write(password)
+	fmt.Print("\n\n")
+	for {
+		confirmation, err := inputPassword(confirmPassphraseMsg)
+		if err != nil {
+			crypto.Wipe(password)
+			return nil, err
+		}
+		match := bytes.Equal(password, confirmation)
+		crypto.Wipe(confirmation)
+		if match {
+			return password, nil
+		}
+
+		fmt.Println(printPrefix, "passphrases do not match.")
+	}
+}
+
+// asks to overwrite path if it exists, returns false if the user declines
+func confirmOverwrite(path string) (bool, error) {
+	if _, err := os.Stat(path); err != nil {
+		return true, nil
+	}
+	answer, err := yesOrNoQuestion(fmt.Sprintf(overwriteFileMsg, path))
+	return answer == "y", err
 }
@@ -1,16 +1,35 @@
 package repl
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/libonomy/ed25519"
 	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/audit"
+	"github.com/libonomy/wallet-cli/auth"
 	"github.com/libonomy/wallet-cli/client"
+	"github.com/libonomy/wallet-cli/contacts"
+	"github.com/libonomy/wallet-cli/fees"
+	"github.com/libonomy/wallet-cli/filesig"
 	"github.com/libonomy/wallet-cli/log"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/os/crypto/passphrase"
+	"github.com/libonomy/wallet-cli/qr"
+	"github.com/libonomy/wallet-cli/signer"
+	"github.com/libonomy/wallet-cli/spending"
+	"github.com/libonomy/wallet-cli/typeddata"
 	"github.com/libonomy/wallet-cli/wallet/address"
 
 	"github.com/c-bata/go-prompt"
@@ -19,39 +38,106 @@ import (
 const (
 	prefix      = "$ "
 	printPrefix = ">"
+
+	defaultGasLimit = 100
+
+	encryptedFileExt = ".enc"
+	decryptedFileExt = ".dec"
 )
 
 // TestMode variable used for check if unit test is running
 var TestMode = false
 
+// MinPassphraseEntropy is the minimal estimated entropy in bits of new passphrases, see passphrase.Entropy.
+var MinPassphraseEntropy float64 = passphrase.DefaultMinEntropy
+
 type command struct {
 	text        string
 	description string
-	fn          func()
+	fn          func() error
 }
 
 type repl struct {
 	commands []command
 	client   Client
 	input    string
+	params   []string
 }
 
 // Client interface to REPL clients.
 type Client interface {
-	CreateAccount(alias string) *accounts.Account
+	CreateAccount(alias, scheme string, passphrase []byte) (*accounts.Account, error)
 	CurrentAccount() *accounts.Account
 	SetCurrentAccount(a *accounts.Account)
 	AccountInfo(address string) (*accounts.AccountInfo, error)
+	EstimateFees() fees.Estimate
 	NodeInfo() (*client.NodeInfo, error)
 	Sanity() error
-	Transfer(recipient address.Address, nonce, amount, gasPrice, gasLimit uint64, key ed25519.PrivateKey) (string, error)
+	Transfer(recipient address.Address, nonce, amount, gasPrice, gasLimit uint64, from *accounts.Account) (string, error)
+	CheckTransferKey(acc *accounts.Account) error
+	Sign(acc *accounts.Account, msg []byte) ([]byte, error)
+	Signer() signer.Signer
+	DaemonSigner(accs ...*accounts.Account) signer.Signer
 	ListAccounts() []string
 	GetAccount(name string) (*accounts.Account, error)
+	ListArchivedAccounts() []string
+	AccountAddress(name string) (address.Address, error)
+	MatchAccounts(aliases []string, query string) []string
+	SelectAccount(query string) (*accounts.Account, error)
+	CachedBalance(address string) (string, bool)
+	RenameAccount(name, newName string) error
+	SetArchived(name string, archived bool) error
+	DeleteAccount(name string, passphrase []byte) (string, error)
+	IsEncrypted(name string) bool
+	ExportKeystore(name string) ([]byte, error)
+	WritePaperWallet(name, filePath string, withKey bool) (string, error)
+	ImportAccount(alias string, scheme accounts.KeyScheme, priv *crypto.Secret, passphrase []byte) (*accounts.Account, error)
+	AddHSMAccount(alias string, key accounts.HSMKey) (*accounts.Account, error)
+	HSMKey(name string) (*accounts.HSMKey, bool)
+	UnlockAccount(a *accounts.Account, passphrase []byte) error
+	ChangePassphrase(name string, passphrase, newPassphrase []byte) error
+	GenerateVRFKey(name string, passphrase []byte) ([]byte, error)
+	VRFPublicKey(name string) ([]byte, error)
+	SignVRF(name string, passphrase, msg []byte) (output, proof []byte, err error)
+	Decrypt(acc *accounts.Account, ciphertext []byte) ([]byte, error)
 	StoreAccounts() error
+	WalletMetadata() *accounts.Metadata
+	SetWalletPassphrase(passphrase []byte) error
+	CreateWallet(name string, passphrase []byte) error
+	OpenWallet(name string) error
+	CloseWallet()
+	WalletName() string
+	ListWallets() []string
+	DefaultWallet() string
+	SetDefaultWallet(name string) error
+	WalletServer() string
+	SetWalletServer(server string) error
+	SetNetworkID(id int8) error
+	KDFParams() crypto.KDParams
+	SetKDFParams(p crypto.KDParams) error
 	NodeURL() string
 	Rebel(datadir string, space uint, coinbase string) error
 	ListTxs(address string) ([]string, error)
 	SetCoinbase(coinbase string) error
+	AccountByAddress(addr address.Address) (string, bool)
+	AddContact(name, addr, note string) (*contacts.Contact, error)
+	RemoveContact(name string) error
+	RenameContact(name, newName string) error
+	GetContact(name string) (*contacts.Contact, error)
+	ContactByAddress(addr address.Address) (*contacts.Contact, bool)
+	ListContacts() []contacts.Contact
+	StoreContacts() error
+	Audit(event string, acc *accounts.Account, details map[string]string)
+	AuditCheckpoint(acc *accounts.Account) error
+	VerifyAudit() (*audit.Report, error)
+	AuditLogPath() string
+	SpendingPolicy(name string) (p *spending.Policy, spentDay, spentWeek uint64, err error)
+	SetSpendingPolicy(name string, p *spending.Policy, passphrase []byte) error
+	CheckSpending(from *accounts.Account, recipient address.Address, amount, gasPrice uint64) []string
+	OverrideSpending(from *accounts.Account, recipient address.Address, amount, gasPrice uint64, passphrase []byte) error
+	ApprovalPolicy(name string) ([]byte, error)
+	SetApprovalPolicy(name string, policy []byte, passphrase []byte) error
+	TransferApproved(a client.Approved, from *accounts.Account) (string, error)
 
 	//Unlock(passphrase string) error
 	//IsAccountUnLock(id string) bool
@@ -63,13 +149,18 @@ type Client interface {
 	//Setup(allocation string) error
 }
 
-// Start starts REPL.
-func Start(c Client) {
+// Start starts REPL. If account is not empty the account it selects is set as current.
+func Start(c Client, account string) {
 	if !TestMode {
 		r := &repl{client: c}
 		r.initializeCommands()
+		if account != "" {
+			if err := r.selectAccount(account); err != nil {
+				log.Error("%v", err)
+			}
+		}
 
-		runPrompt(r.executor, r.completer, r.firstTime, uint16(len(r.commands)))
+		runPrompt(r.executor, r.completer, r.firstTime, r.livePrefix, uint16(len(r.commands)))
 	} else {
 		// holds for unit test purposes
 		hold := make(chan bool)
@@ -77,29 +168,126 @@ func Start(c Client) {
 	}
 }
 
+// Exec runs a single command non-interactively and returns its error. If account is not empty the account it
+// selects is set as current. A command that needs more input than its params fails instead of prompting for it.
+func Exec(c Client, account string, args []string) error {
+	interactive = false
+	defer func() { interactive = true }()
+
+	r := &repl{client: c}
+	r.initializeCommands()
+	if c.WalletMetadata().IsTampered() {
+		fmt.Fprint(os.Stderr, tamperWarningMsg)
+	}
+	if account != "" {
+		if err := r.selectAccount(account); err != nil {
+			return err
+		}
+	}
+
+	text := strings.Join(args, " ")
+	cmd := r.command(text)
+	if cmd == nil {
+		return fmt.Errorf("invalid command `%s`", text)
+	}
+	return cmd.fn()
+}
+
+// selectAccount sets the account selected by query as current, see accounts.Store.MatchAccounts.
+func (r *repl) selectAccount(query string) error {
+	account, err := r.client.SelectAccount(query)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Loaded account alias: `%s`, address: %s \n", printPrefix, account.Name, accounts.StringAddress(account.Address()))
+	r.client.SetCurrentAccount(account)
+	return nil
+}
+
 func (r *repl) initializeCommands() {
 	r.commands = []command{
-		{"create-account", "Create a new account (key pair) and set as current", r.createAccount},
+		{"create-account", "Create a new account (key pair) and set as current, optionally giving the key scheme", r.createAccount},
+		{"add-hsm-account", "Add an account whose ed25519 key is kept in a PKCS#11 token, it signs messages but cannot transfer [module path]", r.addHSMAccount},
 		{"use-previous", "Set one of the previously created accounts as current", r.chooseAccount},
-		{"info", "Display the current account info", r.accountInfo},
+		{"rename-account", "Change the alias of an account", r.renameAccount},
+		{"archive-account", "Hide an account from the accounts list without deleting it", r.archiveAccount},
+		{"unarchive-account", "Restore an archived account to the accounts list", r.unarchiveAccount},
+		{"delete-account", "Delete an account after writing an encrypted backup (--force to ignore balance)", r.deleteAccount},
+		{"backup-shares", "Split the current account key into n-of-m recovery shares (--words for word encoding)", r.backupShares},
+		{"recover-from-shares", "Rebuild an account from recovery shares and import it", r.recoverFromShares},
+		{"qr", "Display the current account address as a QR code (--keystore for the encrypted key)", r.showQR},
+		{"paper-wallet", "Write a printable paper wallet of the current account [path] (--no-key to omit the encrypted key)", r.paperWallet},
+		{"vanity", "Generate an account whose address starts or ends with the given hex characters (Ctrl-C cancels)", r.vanity},
+		{"lock", "Wipe the current account private key from memory", r.lockAccount},
+		{"change-passphrase", "Change the passphrase encrypting the current account private key", r.changePassphrase},
+		{"kdf-calibrate", "Benchmark the key derivation [scrypt|argon2id] [target time] and use it for new keys", r.calibrateKDF},
+		{"wallet-passphrase", "Set or change the passphrase protecting the wallet file integrity", r.walletPassphrase},
+		{"wallet create", "Create a new named wallet [name]", r.createWallet},
+		{"wallet open", "Open a named wallet [name], closing the current one", r.openWallet},
+		{"wallet list", "List the wallets in the data directory", r.listWallets},
+		{"wallet close", "Close the current wallet and wipe unlocked keys from memory", r.closeWallet},
+		{"wallet default", "Set the wallet opened at start [name]", r.defaultWallet},
+		{"wallet node", "Set the node host:port of the current wallet [host:port]", r.walletNode},
+		{"wallet network", "Set the network ID of the current wallet [id]", r.walletNetwork},
+		{"info", "Display the current account info (--private to show the private key)", r.accountInfo},
 		{"status", "Display the node status", r.nodeInfo},
+		{"sign-file", "Write a detached signature of a file <path> with the current account (--sha256 instead of SHA3-256)", r.signFile},
+		{"verify-file", "Verify the detached signature of a file <path> [signature path]", r.verifyFile},
+		{"sign-typed", "Review and sign typed structured data read from a JSON file [path] or pasted", r.signTyped},
+		{"auth-respond", "Sign a login challenge [challenge] proving control of the current account address (--json for JSON output)", r.authRespond},
 		{"sign", "Sign a hex message with the current account private key", r.sign},
 		{"textsign", "Sign a text message with the current account private key", r.textsign},
+		{"verify", "Verify a text message signature with a public key", r.verify},
+		{"vrf-keygen", "Generate a VRF key for the current account, stored encrypted with the account passphrase", r.vrfKeygen},
+		{"vrf-sign", "Compute the VRF output and proof of a hex message (--base64 for base64 input and output)", r.vrfSign},
+		{"vrf-verify", "Verify a VRF proof of a hex message and show its output (--base64 for base64 input and output)", r.vrfVerify},
+		{"encrypt", "Encrypt a text message or a file [path] to an account public key", r.encrypt},
+		{"decrypt", "Decrypt a hex message or a file [path] with the current account private key", r.decrypt},
+		{"approval create", "Write an unsigned transfer of the current account for approval [envelope path]", r.createEnvelope},
+		{"approval sign", "Approve the transfer of an envelope <envelope path> with the current account", r.signEnvelope},
+		{"approval add", "Add detached approvals to an envelope <envelope path> <approval path>...", r.addApprovals},
+		{"approval status", "Show the approvals of an envelope required by the sender policy <envelope path> [policy path]", r.envelopeStatus},
+		{"approval broadcast", "Sign and send an envelope transfer approved as required by the sender policy <envelope path>", r.broadcastEnvelope},
+		{"approval policy show", "Show the approval policy of the current account", r.showApprovalPolicy},
+		{"approval policy set", "Require approvals for the transfers of the current account <policy path>", r.setApprovalPolicy},
+		{"approval policy remove", "Remove the approval policy of the current account", r.removeApprovalPolicy},
+		{"audit verify", "Check the audit log for deleted or modified entries and show its signed checkpoints", r.verifyAudit},
+		{"audit checkpoint", "Sign the audit log entries so far with the current account", r.auditCheckpoint},
+		{"spending show", "Display the spending policy of the current account and the amounts sent", r.showSpending},
+		{"spending set", "Set the spending limits and recipient lists of the current account", r.setSpending},
+		{"spending remove", "Remove the spending policy of the current account", r.removeSpending},
+		{"transfer", "Transfer coins from the current account to another address or contact [gas limit]", r.transferCoins},
+		{"contacts add", "Add a named address to the address book", r.addContact},
+		{"contacts list", "List the address book contacts", r.listContacts},
+		{"contacts remove", "Remove a contact from the address book", r.removeContact},
+		{"contacts rename", "Rename a contact in the address book", r.renameContact},
 		{"quit", "Quit the CLI", r.quit},
 	}
 }
 
-func (r *repl) executor(text string) {
+// command returns the command text starts with and sets its input and params, nil if there is none.
+func (r *repl) command(text string) *command {
 	for _, c := range r.commands {
 		if len(text) >= len(c.text) && text[:len(c.text)] == c.text {
 			r.input = text
+			r.params = strings.Fields(text[len(c.text):])
 			//log.Debug(userExecutingCommandMsg, c.text)
-			c.fn()
-			return
+			return &c
 		}
 	}
+	return nil
+}
 
-	fmt.Println(printPrefix, "invalid command.")
+func (r *repl) executor(text string) {
+	c := r.command(text)
+	if c == nil {
+		fmt.Println(printPrefix, "invalid command.")
+		return
+	}
+	if err := c.fn(); err != nil {
+		log.Error("%v", err)
+	}
 }
 
 func (r *repl) completer(in prompt.Document) []prompt.Suggest {
@@ -113,7 +301,7 @@ func (r *repl) completer(in prompt.Document) []prompt.Suggest {
 		suggets = append(suggets, s)
 	}
 
-	return prompt.FilterHasPrefix(suggets, in.GetWordBeforeCursor(), true)
+	return prompt.FilterHasPrefix(suggets, in.TextBeforeCursor(), true)
 }
 
 func (r *repl) firstTime() {
@@ -124,226 +312,1749 @@ func (r *repl) firstTime() {
 	}
 
 	fmt.Println("Welcome to libonomy. Connected to node at ", r.client.NodeURL())
+	fmt.Printf("%s Opened wallet `%s` \n", printPrefix, r.client.WalletName())
+
+	meta := r.client.WalletMetadata()
+	if meta.IsTampered() {
+		fmt.Print(tamperWarningMsg)
+	} else if meta.IsLegacy() {
+		fmt.Print(legacyWalletMsg)
+	}
 }
 
-func (r *repl) chooseAccount() {
-	accs := r.client.ListAccounts()
-	if len(accs) == 0 {
-		r.createAccount()
-		return
+// livePrefix returns the prompt prefix showing the open wallet and the current account.
+func (r *repl) livePrefix() (string, bool) {
+	name := r.client.WalletName()
+	if name == "" {
+		return prefix, true
 	}
+	if acc := r.client.CurrentAccount(); acc != nil {
+		name += ":" + acc.Name
+	}
+	return name + prefix, true
+}
 
-	fmt.Println(printPrefix, "Choose an account to load:")
-	accName := multipleChoice(accs)
-	account, err := r.client.GetAccount(accName)
+// WalletPassphrase asks the user for the passphrase protecting the wallet file.
+func WalletPassphrase() []byte {
+	passphrase, err := inputPassword(walletPassphraseMsg)
 	if err != nil {
-		panic("wtf")
+		fmt.Println(printPrefix, err)
 	}
-	fmt.Printf("%s Loaded account alias: `%s`, address: %s \n", printPrefix, account.Name, accounts.StringAddress(account.Address()))
+	return passphrase
+}
 
-	r.client.SetCurrentAccount(account)
+// NewWalletPassphrase asks the user to choose the passphrase protecting a wallet file stored for the first time.
+func NewWalletPassphrase() []byte {
+	fmt.Println(printPrefix, protectWalletMsg)
+	passphrase, err := inputStrongPassword(newWalletPassphraseMsg)
+	if err != nil {
+		fmt.Println(printPrefix, err)
+	}
+	return passphrase
 }
 
-func (r *repl) createAccount() {
-	fmt.Println(printPrefix, "Create a new account")
-	alias := inputNotBlank(createAccountMsg)
+func (r *repl) walletPassphrase() error {
+	meta := r.client.WalletMetadata()
+	if meta.IsTampered() {
+		return accounts.ErrTampered
+	}
+
+	if meta.IsProtected() {
+		current, err := inputPassword(walletPassphraseMsg)
+		if err != nil {
+			return err
+		}
+		ok := meta.CheckPassphrase(current)
+		crypto.Wipe(current)
+		if !ok {
+			return errors.New("wrong wallet passphrase")
+		}
+	}
 
-	ac := r.client.CreateAccount(alias)
-	err := r.client.StoreAccounts()
+	passphrase, err := inputStrongPassword(newWalletPassphraseMsg)
 	if err != nil {
-		log.Error("failed to create account: %v", err)
-		return
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+	if err := r.client.SetWalletPassphrase(passphrase); err != nil {
+		return fmt.Errorf("failed to set wallet passphrase: %v", err)
 	}
 
-	fmt.Printf("%s Created account alias: `%s`, address: %s \n", printPrefix, ac.Name, accounts.StringAddress(ac.Address()))
-	r.client.SetCurrentAccount(ac)
+	fmt.Println(printPrefix, "Wallet passphrase set.")
+	return nil
 }
 
-func (r *repl) commandLineParams(idx int, input string) string {
-	c := r.commands[idx]
-	params := strings.Replace(input, c.text, "", -1)
+func (r *repl) calibrateKDF() error {
+	algorithm, target := crypto.KDFArgon2id, time.Second
+	if len(r.params) > 0 {
+		algorithm = r.params[0]
+	}
+	if len(r.params) > 1 {
+		d, err := time.ParseDuration(r.params[1])
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid target time `%s`, use a duration such as 500ms or 2s", r.params[1])
+		}
+		target = d
+	}
 
-	return strings.TrimSpace(params)
+	fmt.Println(printPrefix, "Current key derivation:", r.client.KDFParams())
+	fmt.Println(printPrefix, fmt.Sprintf("Benchmarking %s for a %v unlock time...", algorithm, target))
+	p, err := crypto.Calibrate(algorithm, target)
+	if err != nil {
+		return fmt.Errorf("failed to calibrate key derivation: %v", err)
+	}
+	fmt.Println(printPrefix, "Calibrated key derivation:", p)
+
+	answer, err := yesOrNoQuestion(confirmKDFMsg)
+	if err != nil || answer == "n" {
+		return err
+	}
+	if err := r.client.SetKDFParams(p); err != nil {
+		return fmt.Errorf("failed to store key derivation params: %v", err)
+	}
+
+	fmt.Println(printPrefix, "New keys and passphrases are derived with", p)
+	return nil
 }
 
-func (r *repl) accountInfo() {
-	acc := r.client.CurrentAccount()
-	if acc == nil {
-		r.chooseAccount()
-		acc = r.client.CurrentAccount()
+// walletName returns the wallet name given as the first command param or asks the user for one.
+func (r *repl) walletName() (string, error) {
+	if len(r.params) > 0 {
+		return r.params[0], nil
 	}
+	name, err := inputNotBlankWithCompletion(walletNameMsg, r.walletCompleter)
+	return strings.TrimSpace(name), err
+}
 
-	address := address.BytesToAddress(acc.PubKey)
+func (r *repl) walletCompleter(in prompt.Document) []prompt.Suggest {
+	suggests := make([]prompt.Suggest, 0)
+	for _, name := range r.client.ListWallets() {
+		suggests = append(suggests, prompt.Suggest{Text: name})
+	}
+	return prompt.FilterHasPrefix(suggests, in.TextBeforeCursor(), true)
+}
 
-	info, err := r.client.AccountInfo(hex.EncodeToString(address.Bytes()))
+// requireWallet returns client.ErrNoWallet if no wallet is open.
+func (r *repl) requireWallet() error {
+	if r.client.WalletName() == "" {
+		return client.ErrNoWallet
+	}
+	return nil
+}
+
+func (r *repl) createWallet() error {
+	name, err := r.walletName()
 	if err != nil {
-		log.Error("failed to get account info: %v", err)
-		info = &accounts.AccountInfo{}
+		return err
+	}
+	passphrase, err := inputStrongPassword(newWalletPassphraseMsg)
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+	if err := r.client.CreateWallet(name, passphrase); err != nil {
+		return fmt.Errorf("failed to create wallet: %v", err)
 	}
 
-	fmt.Println(printPrefix, "Local alias: ", acc.Name)
-	fmt.Println(printPrefix, "Address: ", accounts.StringAddress(address))
-	fmt.Println(printPrefix, "Balance: ", info.Balance)
-	fmt.Println(printPrefix, "Nonce: ", info.Nonce)
-	fmt.Println(printPrefix, fmt.Sprintf("Public key: 0x%s", hex.EncodeToString(acc.PubKey)))
-	fmt.Println(printPrefix, fmt.Sprintf("Private key: 0x%s", hex.EncodeToString(acc.PrivKey)))
+	fmt.Printf("%s Created wallet `%s`, run `wallet open %s` to use it \n", printPrefix, name, name)
+	return nil
 }
 
-func (r *repl) nodeInfo() {
-	info, err := r.client.NodeInfo()
+func (r *repl) openWallet() error {
+	name, err := r.walletName()
 	if err != nil {
-		log.Error("failed to get node info: %v", err)
-		return
+		return err
+	}
+	if err := r.client.OpenWallet(name); err == accounts.ErrTampered {
+		fmt.Print(tamperWarningMsg)
+	} else if err != nil {
+		return fmt.Errorf("failed to open wallet: %v", err)
 	}
 
-	fmt.Println(printPrefix, "Synced:", info.Synced)
-	fmt.Println(printPrefix, "Synced layer:", info.SyncedLayer)
-	fmt.Println(printPrefix, "Current layer:", info.CurrentLayer)
-	fmt.Println(printPrefix, "Verified layer:", info.VerifiedLayer)
-	fmt.Println(printPrefix, "Peers:", info.Peers)
-	fmt.Println(printPrefix, "Min peers:", info.MinPeers)
-	fmt.Println(printPrefix, "Max peers:", info.MaxPeers)
-	fmt.Println(printPrefix, "Libonomy data directory:", info.LibonomyDatadir)
-	fmt.Println(printPrefix, "Libonomy status:", info.LibonomyStatus)
-	fmt.Println(printPrefix, "Libonomy coinbase:", info.LibonomyCoinbase)
-	fmt.Println(printPrefix, "Libonomy remaining bytes:", info.LibonomyRemainingBytes)
+	fmt.Printf("%s Opened wallet `%s`, node: %s \n", printPrefix, name, r.client.NodeURL())
+	if r.client.WalletMetadata().IsLegacy() {
+		fmt.Print(legacyWalletMsg)
+	}
+	return nil
 }
 
-func (r *repl) transferCoins() {
-	fmt.Println(printPrefix, initialTransferMsg)
-	acc := r.client.CurrentAccount()
-	if acc == nil {
-		r.chooseAccount()
-		acc = r.client.CurrentAccount()
+func (r *repl) listWallets() error {
+	for _, name := range r.client.ListWallets() {
+		var marks []string
+		if name == r.client.WalletName() {
+			marks = append(marks, "open")
+		}
+		if name == r.client.DefaultWallet() {
+			marks = append(marks, "default")
+		}
+
+		if len(marks) > 0 {
+			fmt.Println(printPrefix, fmt.Sprintf("%s (%s)", name, strings.Join(marks, ", ")))
+		} else {
+			fmt.Println(printPrefix, name)
+		}
+	}
+	return nil
+}
+
+func (r *repl) closeWallet() error {
+	name := r.client.WalletName()
+	if name == "" {
+		fmt.Println(printPrefix, "No wallet is open.")
+		return nil
 	}
 
-	srcAddress := address.BytesToAddress(acc.PubKey)
-	info, err := r.client.AccountInfo(hex.EncodeToString(srcAddress.Bytes()))
+	r.client.CloseWallet()
+	fmt.Printf("%s Closed wallet `%s` \n", printPrefix, name)
+	return nil
+}
+
+func (r *repl) defaultWallet() error {
+	name, err := r.walletName()
 	if err != nil {
-		log.Error("failed to get account info: %v", err)
-		return
+		return err
+	}
+	if err := r.client.SetDefaultWallet(name); err != nil {
+		return fmt.Errorf("failed to set default wallet: %v", err)
 	}
 
-	destAddressStr := inputNotBlank(destAddressMsg)
-	destAddress := address.HexToAddress(destAddressStr)
+	fmt.Printf("%s Wallet `%s` is opened at start \n", printPrefix, name)
+	return nil
+}
 
-	amountStr := inputNotBlank(amountToTransferMsg)
+func (r *repl) walletNode() error {
+	if err := r.requireWallet(); err != nil {
+		return err
+	}
 
-	gas := uint64(1)
-	if yesOrNoQuestion(useDefaultGasMsg) == "n" {
-		gasStr := inputNotBlank(enterGasPrice)
-		gas, err = strconv.ParseUint(gasStr, 10, 64)
+	var server string
+	if len(r.params) > 0 {
+		server = r.params[0]
+	} else {
+		s, err := input(walletNodeMsg)
 		if err != nil {
-			log.Error("invalid gas", err)
-			return
+			return err
 		}
+		server = strings.TrimSpace(s)
 	}
 
-	fmt.Println(printPrefix, "Transaction summary:")
-	fmt.Println(printPrefix, "From:  ", srcAddress.String())
-	fmt.Println(printPrefix, "To:    ", destAddress.String())
-	fmt.Println(printPrefix, "Amount:", amountStr)
-	fmt.Println(printPrefix, "Gas:   ", gas)
-	fmt.Println(printPrefix, "Nonce: ", info.Nonce)
+	if err := r.client.SetWalletServer(server); err != nil {
+		return fmt.Errorf("failed to set wallet node: %v", err)
+	}
 
-	nonce, err := strconv.ParseUint(info.Nonce, 10, 64)
-	amount, err := strconv.ParseUint(amountStr, 10, 64)
+	fmt.Printf("%s Wallet `%s` uses node %s \n", printPrefix, r.client.WalletName(), r.client.NodeURL())
+	if err := r.client.Sanity(); err != nil {
+		log.Error("Failed to connect to node at %v: %v", r.client.NodeURL(), err)
+	}
+	return nil
+}
 
-	if yesOrNoQuestion(confirmTransactionMsg) == "y" {
-		id, err := r.client.Transfer(destAddress, nonce, amount, gas, 100, acc.PrivKey)
-		if err != nil {
-			log.Error(err.Error())
-			return
+func (r *repl) walletNetwork() error {
+	if err := r.requireWallet(); err != nil {
+		return err
+	}
+
+	var idStr string
+	if len(r.params) > 0 {
+		idStr = r.params[0]
+	} else {
+		var err error
+		if idStr, err = inputNotBlank(networkIDMsg); err != nil {
+			return err
 		}
-		fmt.Println(printPrefix, fmt.Sprintf("tx submitted, id: %v", id))
 	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(idStr), 10, 8)
+	if err != nil {
+		return fmt.Errorf("invalid network ID: %v", err)
+	}
+	if err := r.client.SetNetworkID(int8(id)); err != nil {
+		return fmt.Errorf("failed to set network ID: %v", err)
+	}
+
+	fmt.Printf("%s Wallet `%s` uses network %d \n", printPrefix, r.client.WalletName(), id)
+	return nil
 }
 
-func (r *repl) rebel() {
-	acc := r.client.CurrentAccount()
-	if acc == nil {
-		r.chooseAccount()
-		acc = r.client.CurrentAccount()
+// currentAccount returns the current account, asking the user to choose one if none is set.
+func (r *repl) currentAccount() (*accounts.Account, error) {
+	if acc := r.client.CurrentAccount(); acc != nil {
+		return acc, nil
+	}
+	if err := r.requireWallet(); err != nil {
+		return nil, err
 	}
 
-	datadir := inputNotBlank(libonomyDatadirMsg)
+	if err := r.chooseAccount(); err != nil {
+		return nil, err
+	}
+	return r.client.CurrentAccount(), nil
+}
 
-	spaceStr := inputNotBlank(libonomySpaceAllocationMsg)
-	space, err := strconv.ParseUint(spaceStr, 10, 32)
+// unlockedAccount returns the current account, asking the user for the passphrase if it is locked.
+func (r *repl) unlockedAccount() (*accounts.Account, error) {
+	acc, err := r.currentAccount()
+	if err != nil || !acc.IsLocked() {
+		return acc, err
+	}
+	if err := r.unlock(acc); err != nil {
+		return nil, fmt.Errorf("failed to unlock account: %v", err)
+	}
+	return acc, nil
+}
+
+// signingAccount returns the current account to sign with. It is unlocked unless signing is delegated to a signer
+// daemon or to the token holding its key.
+func (r *repl) signingAccount() (*accounts.Account, error) {
+	acc, err := r.currentAccount()
 	if err != nil {
-		log.Error("failed to parse: %v", err)
-		return
+		return nil, err
+	}
+	if _, ok := r.client.HSMKey(acc.Name); ok || r.client.Signer() != nil {
+		return acc, nil
 	}
+	return r.unlockedAccount()
+}
 
-	if err := r.client.Rebel(datadir, uint(space)<<30, accounts.StringAddress(acc.Address())); err != nil {
-		log.Error("failed to start libonomy: %v", err)
-		return
+// unlock asks the user for the passphrase of acc and unlocks it, choosing a passphrase for unencrypted accounts.
+func (r *repl) unlock(acc *accounts.Account) error {
+	if key, ok := r.client.HSMKey(acc.Name); ok {
+		return fmt.Errorf("account `%s` is kept in %s, its private key cannot be unlocked", acc.Name, key)
+	}
+
+	var passphrase []byte
+	var err error
+	if r.client.IsEncrypted(acc.Name) {
+		passphrase, err = inputPassword(accountPassphrase)
+	} else {
+		fmt.Println(printPrefix, fmt.Sprintf("Account `%s` is stored unencrypted, choose a passphrase to encrypt it.", acc.Name))
+		passphrase, err = inputNewAccountPassword()
 	}
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+
+	return r.client.UnlockAccount(acc, passphrase)
 }
 
-func (r *repl) listTxs() {
+func (r *repl) lockAccount() error {
 	acc := r.client.CurrentAccount()
 	if acc == nil {
-		r.chooseAccount()
-		acc = r.client.CurrentAccount()
+		fmt.Println(printPrefix, "No current account.")
+		return nil
 	}
 
-	txs, err := r.client.ListTxs(accounts.StringAddress(acc.Address()))
+	acc.Lock()
+	fmt.Printf("%s Locked account `%s` \n", printPrefix, acc.Name)
+	return nil
+}
+
+func (r *repl) changePassphrase() error {
+	acc, err := r.currentAccount()
 	if err != nil {
-		log.Error("failed to list txs: %v", err)
-		return
+		return err
+	}
+	if !r.client.IsEncrypted(acc.Name) {
+		// unlocking a legacy account asks for a new passphrase and encrypts the key
+		_, err := r.unlockedAccount()
+		return err
 	}
 
-	fmt.Println(printPrefix, fmt.Sprintf("txs: %v", txs))
+	current, err := inputPassword(accountPassphrase)
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(current)
+	newPassphrase, err := inputNewAccountPassword()
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(newPassphrase)
+
+	if err := r.client.ChangePassphrase(acc.Name, current, newPassphrase); err != nil {
+		return fmt.Errorf("failed to change passphrase: %v", err)
+	}
+	if err := r.client.StoreAccounts(); err != nil {
+		return fmt.Errorf("failed to store accounts: %v", err)
+	}
+
+	fmt.Printf("%s Changed passphrase of account `%s` \n", printPrefix, acc.Name)
+	return nil
 }
 
-func (r *repl) quit() {
-	os.Exit(0)
+func (r *repl) chooseAccount() error {
+	accs := r.client.ListAccounts()
+	if len(accs) == 0 {
+		return r.createAccount()
+	}
+
+	fmt.Println(printPrefix, "Choose an account to load:")
+	accName, err := r.pickAccount(accs)
+	if err != nil {
+		return err
+	}
+	account, err := r.client.GetAccount(accName)
+	if err != nil {
+		return fmt.Errorf("failed to load account: %v", err)
+	}
+	fmt.Printf("%s Loaded account alias: `%s`, address: %s \n", printPrefix, account.Name, accounts.StringAddress(account.Address()))
+
+	r.client.SetCurrentAccount(account)
+	return nil
 }
 
-func (r *repl) coinbase() {
-	acc := r.client.CurrentAccount()
-	if acc == nil {
-		r.chooseAccount()
-		acc = r.client.CurrentAccount()
+func (r *repl) createAccount() error {
+	if err := r.requireWallet(); err != nil {
+		return err
 	}
 
-	if err := r.client.SetCoinbase(accounts.StringAddress(acc.Address())); err != nil {
-		log.Error("failed to set coinbase: %v", err)
-		return
+	fmt.Println(printPrefix, "Create a new account")
+	alias, err := inputNotBlank(createAccountMsg)
+	if err != nil {
+		return err
+	}
+
+	scheme := accounts.DefaultScheme
+	if len(r.params) > 0 {
+		scheme = r.params[0]
+	}
+
+	passphrase, err := inputNewAccountPassword()
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+
+	ac, err := r.client.CreateAccount(alias, scheme, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to create account: %v", err)
+	}
+	err = r.client.StoreAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to create account: %v", err)
+	}
+
+	fmt.Printf("%s Created %s account alias: `%s`, address: %s \n", printPrefix, ac.Scheme.Name(), ac.Name, accounts.StringAddress(ac.Address()))
+	r.client.SetCurrentAccount(ac)
+	return nil
+}
+
+// TokenPIN asks the user for the PIN of the PKCS#11 token labelled token.
+func TokenPIN(token string) []byte {
+	pin, err := inputPassword(fmt.Sprintf(tokenPINMsg, token))
+	if err != nil {
+		fmt.Println(printPrefix, err)
 	}
+	return pin
 }
 
-func (r *repl) sign() {
-	acc := r.client.CurrentAccount()
-	if acc == nil {
-		r.chooseAccount()
-		acc = r.client.CurrentAccount()
+func (r *repl) addHSMAccount() error {
+	if err := r.requireWallet(); err != nil {
+		return err
 	}
 
-	msgStr := inputNotBlank(msgSignMsg)
-	msg, err := hex.DecodeString(msgStr)
+	answer, err := yesOrNoQuestion(hsmNoTransferMsg)
+	if err != nil || answer == "n" {
+		return err
+	}
+
+	key := accounts.HSMKey{}
+	if len(r.params) > 0 {
+		key.Module = r.params[0]
+	} else {
+		module, err := inputNotBlank(hsmModuleMsg)
+		if err != nil {
+			return err
+		}
+		key.Module = strings.TrimSpace(module)
+	}
+	token, err := inputNotBlank(hsmTokenMsg)
 	if err != nil {
-		log.Error("failed to decode msg hex string: %v", err)
-		return
+		return err
+	}
+	key.Token = strings.TrimSpace(token)
+	keyID, err := inputNotBlank(hsmKeyIDMsg)
+	if err != nil {
+		return err
+	}
+	key.KeyID = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(keyID), "0x"))
+	alias, err := inputNotBlank(createAccountMsg)
+	if err != nil {
+		return err
 	}
 
-	signature := ed25519.Sign2(acc.PrivKey, msg)
+	acc, err := r.client.AddHSMAccount(alias, key)
+	if err != nil {
+		return fmt.Errorf("failed to add token account: %v", err)
+	}
 
-	fmt.Println(printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
+	fmt.Printf("%s Added account alias: `%s`, address: %s, kept in %s \n", printPrefix, acc.Name, accounts.StringAddress(acc.Address()), key)
+	r.client.SetCurrentAccount(acc)
+	return nil
 }
 
-func (r *repl) textsign() {
-	acc := r.client.CurrentAccount()
-	if acc == nil {
-		r.chooseAccount()
-		acc = r.client.CurrentAccount()
+// accountAlias returns the alias given as the first command param or asks the user to choose one of aliases.
+func (r *repl) accountAlias(aliases []string) (string, error) {
+	if len(r.params) > 0 && !strings.HasPrefix(r.params[0], "--") {
+		matches := r.client.MatchAccounts(aliases, r.params[0])
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+		fmt.Println(printPrefix, fmt.Sprintf("`%s` does not select a single account.", r.params[0]))
 	}
 
-	msg := inputNotBlank(msgTextSignMsg)
-	signature := ed25519.Sign2(acc.PrivKey, []byte(msg))
+	fmt.Println(printPrefix, "Choose an account:")
+	return r.pickAccount(aliases)
+}
 
-	fmt.Println(printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
+// hasFlag returns true iff flag was passed as a command param.
+func (r *repl) hasFlag(flag string) bool {
+	for _, p := range r.params {
+		if p == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *repl) renameAccount() error {
+	alias, err := r.accountAlias(r.client.ListAccounts())
+	if err != nil {
+		return err
+	}
+	newAlias, err := inputNotBlank(newAccountAliasMsg)
+	if err != nil {
+		return err
+	}
+	if err := r.client.RenameAccount(alias, newAlias); err != nil {
+		return fmt.Errorf("failed to rename account: %v", err)
+	}
+
+	if err := r.client.StoreAccounts(); err != nil {
+		return fmt.Errorf("failed to store accounts: %v", err)
+	}
+
+	if acc := r.client.CurrentAccount(); acc != nil && acc.Name == alias {
+		acc.Name = strings.TrimSpace(newAlias)
+	}
+	fmt.Printf("%s Renamed account `%s` to `%s` \n", printPrefix, alias, strings.TrimSpace(newAlias))
+	return nil
+}
+
+func (r *repl) archiveAccount() error {
+	alias, err := r.accountAlias(r.client.ListAccounts())
+	if err != nil {
+		return err
+	}
+	if err := r.client.SetArchived(alias, true); err != nil {
+		return fmt.Errorf("failed to archive account: %v", err)
+	}
+
+	if err := r.client.StoreAccounts(); err != nil {
+		return fmt.Errorf("failed to store accounts: %v", err)
+	}
+
+	if acc := r.client.CurrentAccount(); acc != nil && acc.Name == alias {
+		r.client.SetCurrentAccount(nil)
+	}
+	fmt.Printf("%s Archived account `%s` \n", printPrefix, alias)
+	return nil
+}
+
+func (r *repl) unarchiveAccount() error {
+	archived := r.client.ListArchivedAccounts()
+	if len(archived) == 0 {
+		fmt.Println(printPrefix, "There are no archived accounts.")
+		return nil
+	}
+
+	alias, err := r.accountAlias(archived)
+	if err != nil {
+		return err
+	}
+	if err := r.client.SetArchived(alias, false); err != nil {
+		return fmt.Errorf("failed to unarchive account: %v", err)
+	}
+
+	if err := r.client.StoreAccounts(); err != nil {
+		return fmt.Errorf("failed to store accounts: %v", err)
+	}
+
+	fmt.Printf("%s Restored account `%s` \n", printPrefix, alias)
+	return nil
+}
+
+func (r *repl) deleteAccount() error {
+	alias, err := r.accountAlias(append(r.client.ListAccounts(), r.client.ListArchivedAccounts()...))
+	if err != nil {
+		return err
+	}
+	account, err := r.client.GetAccount(alias)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %v", err)
+	}
+
+	force := r.hasFlag("--force")
+	info, err := r.client.AccountInfo(hex.EncodeToString(account.Address().Bytes()))
+	if err != nil && !force {
+		return fmt.Errorf("cannot verify account balance, use --force to delete anyway: %v", err)
+	}
+	if err == nil && info.Balance != "0" && !force {
+		return fmt.Errorf("account `%s` has a balance of %s, use --force to delete anyway", alias, info.Balance)
+	}
+
+	confirmation, err := inputNotBlank(fmt.Sprintf(confirmDeleteAccountMsg, alias))
+	if err != nil {
+		return err
+	}
+	if confirmation != alias {
+		return errors.New("alias mismatch, account not deleted")
+	}
+
+	passphrase, err := inputStrongPassword(backupPassphraseMsg)
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+	backup, err := r.client.DeleteAccount(alias, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %v", err)
+	}
+
+	fmt.Printf("%s Deleted account `%s`, encrypted backup written to %s \n", printPrefix, alias, backup)
+	return nil
+}
+
+func (r *repl) backupShares() error {
+	acc, err := r.unlockedAccount()
+	if err != nil {
+		return err
+	}
+
+	nStr, err := inputNotBlank(sharesCountMsg)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(nStr))
+	if err != nil {
+		return fmt.Errorf("invalid number of shares: %v", err)
+	}
+	thresholdStr, err := inputNotBlank(sharesThresholdMsg)
+	if err != nil {
+		return err
+	}
+	threshold, err := strconv.Atoi(strings.TrimSpace(thresholdStr))
+	if err != nil {
+		return fmt.Errorf("invalid threshold: %v", err)
+	}
+
+	shares, err := accounts.SplitKey(acc, n, threshold)
+	if err != nil {
+		return fmt.Errorf("failed to split account key: %v", err)
+	}
+
+	r.client.Audit("export", acc, map[string]string{"format": "shares", "shares": strconv.Itoa(n), "threshold": strconv.Itoa(threshold)})
+	fmt.Println(printPrefix, fmt.Sprintf("Any %d of the following %d shares recover account `%s` %s.", threshold, n, acc.Name, accounts.StringAddress(acc.Address())))
+	fmt.Println(printPrefix, "Store each share in a different place, they are not encrypted.")
+	for i, share := range shares {
+		encoded := share.Hex()
+		if r.hasFlag("--words") {
+			encoded = share.Words()
+		}
+		fmt.Println(printPrefix, fmt.Sprintf("Share %d/%d: %s", i+1, n, encoded))
+	}
+	return nil
+}
+
+func (r *repl) recoverFromShares() error {
+	shares := make([]*accounts.KeyShare, 0)
+	for len(shares) == 0 || len(shares) < shares[0].Threshold {
+		s, err := inputNotBlank(fmt.Sprintf(enterShareMsg, len(shares)+1))
+		if err != nil {
+			return err
+		}
+		share, err := accounts.ParseKeyShare(s)
+		if err != nil {
+			fmt.Println(printPrefix, fmt.Sprintf("invalid share: %v", err))
+			continue
+		}
+		shares = append(shares, share)
+	}
+
+	scheme, priv, pub, err := accounts.RecoverKey(shares)
+	if err != nil {
+		return fmt.Errorf("failed to recover account key: %v", err)
+	}
+	addr := scheme.Address(pub)
+	fmt.Println(printPrefix, fmt.Sprintf("Recovered %s key of address %s", scheme.Name(), accounts.StringAddress(addr)))
+
+	if alias, ok := r.client.AccountByAddress(addr); ok {
+		priv.Wipe()
+		fmt.Println(printPrefix, fmt.Sprintf("The recovered key matches the existing account `%s`, nothing to import.", alias))
+		return nil
+	}
+
+	alias, err := inputNotBlank(createAccountMsg)
+	if err != nil {
+		priv.Wipe()
+		return err
+	}
+	passphrase, err := inputNewAccountPassword()
+	if err != nil {
+		priv.Wipe()
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+
+	acc, err := r.client.ImportAccount(strings.TrimSpace(alias), scheme, priv, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to import account: %v", err)
+	}
+	if acc.Address() != addr {
+		return fmt.Errorf("imported account address %s does not match the recovered key", accounts.StringAddress(acc.Address()))
+	}
+
+	if err := r.client.StoreAccounts(); err != nil {
+		return fmt.Errorf("failed to store accounts: %v", err)
+	}
+
+	fmt.Printf("%s Imported account alias: `%s`, address: %s \n", printPrefix, acc.Name, accounts.StringAddress(acc.Address()))
+	r.client.SetCurrentAccount(acc)
+	return nil
+}
+
+// encryptedAccount returns the current account, making sure its private key is stored encrypted.
+func (r *repl) encryptedAccount() (*accounts.Account, error) {
+	acc, err := r.currentAccount()
+	if err == nil && !r.client.IsEncrypted(acc.Name) {
+		return r.unlockedAccount()
+	}
+	return acc, err
+}
+
+func (r *repl) showQR() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	content := accounts.StringAddress(acc.Address())
+	if r.hasFlag("--keystore") {
+		if acc, err = r.encryptedAccount(); err != nil {
+			return err
+		}
+		keystore, err := r.client.ExportKeystore(acc.Name)
+		if err != nil {
+			return fmt.Errorf("failed to export keystore: %v", err)
+		}
+		content = string(keystore)
+	}
+
+	code, err := qr.Terminal(content)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %v", err)
+	}
+	fmt.Print(code)
+	fmt.Println(printPrefix, content)
+	return nil
+}
+
+func (r *repl) paperWallet() error {
+	withKey := !r.hasFlag("--no-key")
+	acc, err := r.currentAccount()
+	if withKey {
+		acc, err = r.encryptedAccount()
+	}
+	if err != nil {
+		return err
+	}
+
+	filePath := ""
+	if len(r.params) > 0 && !strings.HasPrefix(r.params[0], "--") {
+		filePath = r.params[0]
+	}
+
+	filePath, err = r.client.WritePaperWallet(acc.Name, filePath, withKey)
+	if err != nil {
+		return fmt.Errorf("failed to write paper wallet: %v", err)
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("Paper wallet of `%s` written to %s", acc.Name, filePath))
+	return nil
+}
+
+func (r *repl) vanity() error {
+	vanityPrefix, err := input(vanityPrefixMsg)
+	if err != nil {
+		return err
+	}
+	vanitySuffix, err := input(vanitySuffixMsg)
+	if err != nil {
+		return err
+	}
+	pattern := accounts.VanityPattern{
+		Prefix: strings.TrimPrefix(strings.TrimSpace(vanityPrefix), "0x"),
+		Suffix: strings.TrimSpace(vanitySuffix),
+	}
+	if err := pattern.Validate(); err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	scheme, err := accounts.GetScheme(accounts.DefaultScheme)
+	if err != nil {
+		return err
+	}
+
+	workers := runtime.NumCPU()
+	fmt.Println(printPrefix, fmt.Sprintf("Expected attempts: %.0f, using %d workers.", pattern.Difficulty(), workers))
+	answer, err := yesOrNoQuestion(startVanityMsg)
+	if err != nil || answer == "n" {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	var attempts uint64
+	type result struct {
+		pub  []byte
+		priv *crypto.Secret
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		pub, priv, err := accounts.GenerateVanity(ctx, scheme, pattern, workers, &attempts)
+		done <- result{pub, priv, err}
+	}()
+
+	start := time.Now()
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	var res result
+	for waiting := true; waiting; {
+		select {
+		case res = <-done:
+			waiting = false
+		case <-interrupt:
+			cancel()
+		case <-ticker.C:
+			n := atomic.LoadUint64(&attempts)
+			rate := float64(n) / time.Since(start).Seconds()
+			if rate == 0 {
+				fmt.Println(printPrefix, fmt.Sprintf("%d attempts so far", n))
+				continue
+			}
+			eta := time.Duration(pattern.Difficulty() / rate * float64(time.Second))
+			fmt.Println(printPrefix, fmt.Sprintf("%d attempts, %.0f/sec, expected time %v, %.1f%% chance so far",
+				n, rate, eta.Round(time.Second), 100*pattern.Probability(n)))
+		}
+	}
+
+	if res.err != nil {
+		return fmt.Errorf("vanity generation stopped: %v", res.err)
+	}
+
+	addr := scheme.Address(res.pub)
+	fmt.Println(printPrefix, fmt.Sprintf("Found %s after %d attempts in %v", addr.Hex(), atomic.LoadUint64(&attempts), time.Since(start).Round(time.Second)))
+
+	alias, err := inputNotBlank(createAccountMsg)
+	if err != nil {
+		res.priv.Wipe()
+		return err
+	}
+	passphrase, err := inputNewAccountPassword()
+	if err != nil {
+		res.priv.Wipe()
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+
+	acc, err := r.client.ImportAccount(strings.TrimSpace(alias), scheme, res.priv, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to create account: %v", err)
+	}
+	if err := r.client.StoreAccounts(); err != nil {
+		return fmt.Errorf("failed to store accounts: %v", err)
+	}
+
+	fmt.Printf("%s Created account alias: `%s`, address: %s \n", printPrefix, acc.Name, acc.Address().Hex())
+	r.client.SetCurrentAccount(acc)
+	return nil
+}
+
+func (r *repl) commandLineParams(idx int, input string) string {
+	c := r.commands[idx]
+	params := strings.Replace(input, c.text, "", -1)
+
+	return strings.TrimSpace(params)
+}
+
+func (r *repl) accountInfo() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	address := acc.Address()
+
+	info, err := r.client.AccountInfo(hex.EncodeToString(address.Bytes()))
+	if err != nil {
+		log.Error("failed to get account info: %v", err)
+		info = &accounts.AccountInfo{}
+	}
+
+	fmt.Println(printPrefix, "Local alias: ", acc.Name)
+	fmt.Println(printPrefix, "Address: ", accounts.StringAddress(address))
+	fmt.Println(printPrefix, "Balance: ", info.Balance)
+	fmt.Println(printPrefix, "Nonce: ", info.Nonce)
+	fmt.Println(printPrefix, "Key scheme: ", acc.Scheme.Name())
+	fmt.Println(printPrefix, fmt.Sprintf("Public key: 0x%s", hex.EncodeToString(acc.PubKey)))
+	if key, ok := r.client.HSMKey(acc.Name); ok {
+		fmt.Println(printPrefix, "Key storage: ", key.String(), "of", key.Module, "(cannot sign transfers)")
+	}
+	if vrfPub, err := r.client.VRFPublicKey(acc.Name); err == nil {
+		fmt.Println(printPrefix, fmt.Sprintf("VRF public key: 0x%s", hex.EncodeToString(vrfPub)))
+	}
+	if r.hasFlag("--private") {
+		if _, err := r.unlockedAccount(); err != nil {
+			return err
+		}
+		r.client.Audit("export", acc, map[string]string{"format": "private-key"})
+		fmt.Println(printPrefix, fmt.Sprintf("Private key: 0x%x", acc.PrivKey.Bytes()))
+	}
+	return nil
+}
+
+func (r *repl) nodeInfo() error {
+	info, err := r.client.NodeInfo()
+	if err != nil {
+		return fmt.Errorf("failed to get node info: %v", err)
+	}
+
+	fmt.Println(printPrefix, "Synced:", info.Synced)
+	fmt.Println(printPrefix, "Synced layer:", info.SyncedLayer)
+	fmt.Println(printPrefix, "Current layer:", info.CurrentLayer)
+	fmt.Println(printPrefix, "Verified layer:", info.VerifiedLayer)
+	fmt.Println(printPrefix, "Peers:", info.Peers)
+	fmt.Println(printPrefix, "Min peers:", info.MinPeers)
+	fmt.Println(printPrefix, "Max peers:", info.MaxPeers)
+	fmt.Println(printPrefix, "Libonomy data directory:", info.LibonomyDatadir)
+	fmt.Println(printPrefix, "Libonomy status:", info.LibonomyStatus)
+	fmt.Println(printPrefix, "Libonomy coinbase:", info.LibonomyCoinbase)
+	fmt.Println(printPrefix, "Libonomy remaining bytes:", info.LibonomyRemainingBytes)
+	return nil
+}
+
+func (r *repl) transferCoins() error {
+	fmt.Println(printPrefix, initialTransferMsg)
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+	if err := r.client.CheckTransferKey(acc); err != nil {
+		return err
+	}
+	if acc, err = r.signingAccount(); err != nil {
+		return err
+	}
+	gasLimit, err := r.gasLimit()
+	if err != nil {
+		return err
+	}
+
+	srcAddress := acc.Address()
+	info, err := r.client.AccountInfo(hex.EncodeToString(srcAddress.Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to get account info: %v", err)
+	}
+	nonce, err := strconv.ParseUint(info.Nonce, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account nonce: %v", err)
+	}
+
+	destAddress, err := r.destinationAddress()
+	if err != nil {
+		return err
+	}
+
+	amountStr, err := inputNotBlank(amountToTransferMsg)
+	if err != nil {
+		return err
+	}
+	amount, err := strconv.ParseUint(amountStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %v", err)
+	}
+
+	gas, err := r.gasPrice()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(printPrefix, "Transaction summary:")
+	fmt.Println(printPrefix, "From:     ", srcAddress.String())
+	fmt.Println(printPrefix, "To:       ", destAddress.String())
+	fmt.Println(printPrefix, "Amount:   ", amount)
+	fmt.Println(printPrefix, "Gas:      ", gas)
+	fmt.Println(printPrefix, "Gas limit:", gasLimit)
+	fmt.Println(printPrefix, "Nonce:    ", info.Nonce)
+	if err := printFees(info.Balance, amount, gas, gasLimit); err != nil {
+		return err
+	}
+
+	answer, err := yesOrNoQuestion(confirmTransactionMsg)
+	if err != nil || answer == "n" {
+		return err
+	}
+	if err := r.allowSpending(acc, destAddress, amount, gas); err != nil {
+		return err
+	}
+	id, err := r.client.Transfer(destAddress, nonce, amount, gas, gasLimit, acc)
+	if err != nil {
+		return err
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("tx submitted, id: %v", id))
+	return nil
+}
+
+// destinationAddress asks the user for a destination address or contact name.
+func (r *repl) destinationAddress() (address.Address, error) {
+	destAddressStr, err := inputNotBlankWithCompletion(destAddressMsg, r.contactCompleter)
+	if err != nil {
+		return address.Address{}, err
+	}
+	destAddressStr = strings.TrimSpace(destAddressStr)
+	if c, err := r.client.GetContact(destAddressStr); err == nil {
+		fmt.Println(printPrefix, fmt.Sprintf("Sending to contact `%s`: %s", c.Name, c.Address))
+		return address.HexToAddress(c.Address), nil
+	}
+	if !address.IsHexAddress(destAddressStr) {
+		return address.Address{}, fmt.Errorf("invalid destination address or contact name: %v", destAddressStr)
+	}
+
+	destAddress := address.HexToAddress(destAddressStr)
+	_, own := r.client.AccountByAddress(destAddress)
+	if _, known := r.client.ContactByAddress(destAddress); !known && !own {
+		fmt.Println(printPrefix, unknownDestAddressMsg)
+	}
+	return destAddress, nil
+}
+
+// gasPrice asks the user for the transaction gas price, suggesting low, normal and fast prices estimated from the
+// recent transactions known to the node.
+func (r *repl) gasPrice() (uint64, error) {
+	estimate := r.client.EstimateFees()
+	fmt.Println(printPrefix, "Suggested gas prices:", estimate)
+	s, err := input(gasPriceMsg)
+	if err != nil {
+		return 0, err
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return estimate.Normal, nil
+	}
+	if price, ok := estimate.Preset(s); ok {
+		return price, nil
+	}
+	gas, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gas: %v", err)
+	}
+	return gas, nil
+}
+
+// gasLimit returns the gas limit given as first parameter, defaultGasLimit if none.
+func (r *repl) gasLimit() (uint64, error) {
+	if len(r.params) == 0 {
+		return defaultGasLimit, nil
+	}
+	limit, err := strconv.ParseUint(r.params[0], 10, 64)
+	if err != nil || limit == 0 {
+		return 0, fmt.Errorf("invalid gas limit: %v", r.params[0])
+	}
+	return limit, nil
+}
+
+// printFees prints the fee of a transfer of amount and the balance left after it. It returns an error if balance
+// does not cover the amount and the fee.
+func printFees(balance string, amount, gasPrice, gasLimit uint64) error {
+	total, fee, err := fees.Total(amount, gasPrice, gasLimit)
+	if err != nil {
+		return fmt.Errorf("invalid transfer: %v", err)
+	}
+	fmt.Println(printPrefix, "Fee:      ", fee)
+	fmt.Println(printPrefix, "Total:    ", total)
+
+	bal, err := strconv.ParseUint(balance, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account balance `%s`: %v", balance, err)
+	}
+	if total > bal {
+		return fmt.Errorf("amount plus fee %d exceeds the account balance %d", total, bal)
+	}
+	fmt.Println(printPrefix, "Balance after transfer:", bal-total)
+	return nil
+}
+
+func (r *repl) rebel() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	datadir, err := inputNotBlank(libonomyDatadirMsg)
+	if err != nil {
+		return err
+	}
+
+	spaceStr, err := inputNotBlank(libonomySpaceAllocationMsg)
+	if err != nil {
+		return err
+	}
+	space, err := strconv.ParseUint(spaceStr, 10, 32)
+	if err != nil {
+		return fmt.Errorf("failed to parse: %v", err)
+	}
+
+	if err := r.client.Rebel(datadir, uint(space)<<30, accounts.StringAddress(acc.Address())); err != nil {
+		return fmt.Errorf("failed to start libonomy: %v", err)
+	}
+	return nil
+}
+
+func (r *repl) listTxs() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	txs, err := r.client.ListTxs(accounts.StringAddress(acc.Address()))
+	if err != nil {
+		return fmt.Errorf("failed to list txs: %v", err)
+	}
+
+	fmt.Println(printPrefix, fmt.Sprintf("txs: %v", txs))
+	return nil
+}
+
+func (r *repl) quit() error {
+	if acc := r.client.CurrentAccount(); acc != nil {
+		acc.Lock()
+	}
+	os.Exit(0)
+	return nil
+}
+
+func (r *repl) coinbase() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.SetCoinbase(accounts.StringAddress(acc.Address())); err != nil {
+		return fmt.Errorf("failed to set coinbase: %v", err)
+	}
+	return nil
+}
+
+func (r *repl) sign() error {
+	acc, err := r.signingAccount()
+	if err != nil {
+		return err
+	}
+
+	msgStr, err := inputNotBlank(msgSignMsg)
+	if err != nil {
+		return err
+	}
+	msg, err := hex.DecodeString(msgStr)
+	if err != nil {
+		return fmt.Errorf("failed to decode msg hex string: %v", err)
+	}
+
+	signature, err := r.client.Sign(acc, msg)
+	if err != nil {
+		return fmt.Errorf("failed to sign msg: %v", err)
+	}
+
+	fmt.Println(printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
+	return nil
+}
+
+func (r *repl) textsign() error {
+	acc, err := r.signingAccount()
+	if err != nil {
+		return err
+	}
+
+	msg, err := inputNotBlank(msgTextSignMsg)
+	if err != nil {
+		return err
+	}
+	signature, err := r.client.Sign(acc, []byte(msg))
+	if err != nil {
+		return fmt.Errorf("failed to sign msg: %v", err)
+	}
+
+	fmt.Println(printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
+	return nil
+}
+
+func (r *repl) verify() error {
+	pubStr, err := inputNotBlank(pubKeyMsg)
+	if err != nil {
+		return err
+	}
+	pubStr = strings.TrimPrefix(strings.TrimSpace(pubStr), "0x")
+	var scheme accounts.KeyScheme
+	if len(r.params) > 0 {
+		scheme, err = accounts.GetScheme(r.params[0])
+	} else {
+		scheme, err = accounts.DetectScheme(pubStr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get key scheme: %v", err)
+	}
+
+	pub, err := scheme.ParsePublicKey(pubStr)
+	if err != nil {
+		return fmt.Errorf("failed to decode public key: %v", err)
+	}
+
+	msg, err := inputNotBlank(msgTextVerifyMsg)
+	if err != nil {
+		return err
+	}
+	sigStr, err := inputNotBlank(signatureMsg)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(sigStr), "0x"))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature hex string: %v", err)
+	}
+
+	if !scheme.Verify(pub, []byte(msg), sig) {
+		return errors.New("signature is NOT valid")
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("signature is valid, signed by %s address %s", scheme.Name(), accounts.StringAddress(scheme.Address(pub))))
+	return nil
+}
+
+func (r *repl) signFile() error {
+	if len(r.params) == 0 || strings.HasPrefix(r.params[0], "--") {
+		return errors.New("usage: sign-file <path> [--sha256]")
+	}
+	path := r.params[0]
+	algorithm := filesig.SHA3256
+	if r.hasFlag("--sha256") {
+		algorithm = filesig.SHA256
+	}
+
+	acc, err := r.signingAccount()
+	if err != nil {
+		return err
+	}
+
+	sigPath := path + filesig.Ext
+	if ok, err := confirmOverwrite(sigPath); !ok || err != nil {
+		return err
+	}
+
+	sig, err := filesig.Sign(path, algorithm, acc, r.client.Sign)
+	if err != nil {
+		return fmt.Errorf("failed to sign file: %v", err)
+	}
+	if err := sig.Write(sigPath); err != nil {
+		return fmt.Errorf("failed to write signature: %v", err)
+	}
+	r.client.Audit("sign-file", acc, map[string]string{"path": path, "algorithm": sig.Algorithm, "digest": sig.Digest})
+
+	fmt.Println(printPrefix, fmt.Sprintf("%s digest: %s", sig.Algorithm, sig.Digest))
+	fmt.Println(printPrefix, fmt.Sprintf("Signature written to %s", sigPath))
+	return nil
+}
+
+func (r *repl) verifyFile() error {
+	if len(r.params) == 0 {
+		return errors.New("usage: verify-file <path> [signature path]")
+	}
+	path := r.params[0]
+	sigPath := path + filesig.Ext
+	if len(r.params) > 1 {
+		sigPath = r.params[1]
+	}
+
+	sig, err := filesig.Read(sigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %v", err)
+	}
+	if err := sig.Verify(path); err != nil {
+		return fmt.Errorf("signature is NOT valid: %v", err)
+	}
+
+	fmt.Println(printPrefix, fmt.Sprintf("signature is valid, %s digest %s signed by %s address %s",
+		sig.Algorithm, sig.Digest, sig.Scheme, r.describeAddress(address.HexToAddress(sig.Signer))))
+	return nil
+}
+
+// describeAddress returns addr along with the alias of the account or contact holding it, if any.
+func (r *repl) describeAddress(addr address.Address) string {
+	s := accounts.StringAddress(addr)
+	if alias, ok := r.client.AccountByAddress(addr); ok {
+		return fmt.Sprintf("%s (your account `%s`)", s, alias)
+	}
+	if c, ok := r.client.ContactByAddress(addr); ok {
+		return fmt.Sprintf("%s (contact `%s`)", s, c.Name)
+	}
+	return s
+}
+
+func (r *repl) signTyped() error {
+	var data []byte
+	if len(r.params) > 0 {
+		var err error
+		if data, err = ioutil.ReadFile(r.params[0]); err != nil {
+			return fmt.Errorf("failed to read typed data: %v", err)
+		}
+	} else {
+		s, err := inputNotBlank(typedDataMsg)
+		if err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+
+	td, err := typeddata.Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid typed data: %v", err)
+	}
+
+	fmt.Println(printPrefix, "You are asked to sign:")
+	fmt.Print(td.Format())
+	if network := r.client.WalletMetadata().NetworkID; td.Domain.NetworkID != network {
+		fmt.Println(printPrefix, fmt.Sprintf(typedDataNetworkMsg, td.Domain.NetworkID, network))
+	}
+	answer, err := yesOrNoQuestion(confirmSignTypedMsg)
+	if err != nil || answer == "n" {
+		return err
+	}
+
+	acc, err := r.signingAccount()
+	if err != nil {
+		return err
+	}
+	signed, err := typeddata.Sign(td, acc, r.client.Sign)
+	if err != nil {
+		return fmt.Errorf("failed to sign typed data: %v", err)
+	}
+	if digest, err := td.Hash(); err == nil {
+		r.client.Audit("sign-typed", acc, map[string]string{"digest": hex.EncodeToString(digest)})
+	}
+	out, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode signed typed data: %v", err)
+	}
+
+	fmt.Println(printPrefix, "Signed typed data:")
+	fmt.Println(string(out))
+	return nil
+}
+
+func (r *repl) authRespond() error {
+	var data string
+	if len(r.params) > 0 {
+		data = strings.Join(r.params, " ")
+	} else {
+		var err error
+		if data, err = inputNotBlank(authChallengeMsg); err != nil {
+			return err
+		}
+	}
+	data = strings.TrimSpace(strings.Replace(data, "--json", "", -1))
+
+	c, err := auth.ParseChallenge(data)
+	if err != nil {
+		return fmt.Errorf("invalid login challenge: %v", err)
+	}
+
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+	requested := "any address"
+	if c.Address != "" {
+		requested = c.Address
+		if address.HexToAddress(c.Address) != acc.Address() {
+			return fmt.Errorf("the challenge requests address %s, the current account `%s` has address %s",
+				c.Address, acc.Name, accounts.StringAddress(acc.Address()))
+		}
+	}
+
+	fmt.Println(printPrefix, "Login challenge:")
+	fmt.Println(printPrefix, "Origin:\t", c.Origin)
+	fmt.Println(printPrefix, "Address:\t", requested)
+	fmt.Println(printPrefix, "Nonce:\t", c.Nonce)
+	fmt.Println(printPrefix, "Expires:\t", c.Expires.Local().Format(time.RFC1123))
+	if c.Expired(time.Now()) {
+		return errors.New("the challenge expired")
+	}
+	answer, err := yesOrNoQuestion(fmt.Sprintf(confirmAuthMsg, c.Origin, acc.Name))
+	if err != nil || answer == "n" {
+		return err
+	}
+
+	if acc, err = r.signingAccount(); err != nil {
+		return err
+	}
+	resp, err := auth.Respond(c, acc, r.client.Sign)
+	if err != nil {
+		return fmt.Errorf("failed to sign the login challenge: %v", err)
+	}
+	r.client.Audit("auth-respond", acc, map[string]string{"origin": c.Origin, "nonce": c.Nonce})
+
+	fmt.Println(printPrefix, "Login response, paste it on", c.Origin+":")
+	if r.hasFlag("--json") {
+		out, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode the login response: %v", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	fmt.Println(resp.Encode())
+	return nil
+}
+
+// decodeBytes decodes s as base64 if the --base64 flag is set, as hex otherwise.
+func (r *repl) decodeBytes(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if r.hasFlag("--base64") {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
+// encodeBytes encodes b as base64 if the --base64 flag is set, as hex otherwise.
+func (r *repl) encodeBytes(b []byte) string {
+	if r.hasFlag("--base64") {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (r *repl) vrfKeygen() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.VRFPublicKey(acc.Name); err == nil {
+		answer, err := yesOrNoQuestion(fmt.Sprintf(replaceVRFKeyMsg, acc.Name))
+		if err != nil || answer == "n" {
+			return err
+		}
+	}
+
+	passphrase, err := inputPassword(accountPassphrase)
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+	pub, err := r.client.GenerateVRFKey(acc.Name, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to generate VRF key: %v", err)
+	}
+	if err := r.client.StoreAccounts(); err != nil {
+		return fmt.Errorf("failed to store accounts: %v", err)
+	}
+
+	fmt.Printf("%s Generated VRF key of account `%s`, public key: 0x%s \n", printPrefix, acc.Name, hex.EncodeToString(pub))
+	return nil
+}
+
+func (r *repl) vrfSign() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.VRFPublicKey(acc.Name); err != nil {
+		return fmt.Errorf("%v, run `vrf-keygen` first", err)
+	}
+
+	msgStr, err := inputNotBlank(vrfMessageMsg)
+	if err != nil {
+		return err
+	}
+	msg, err := r.decodeBytes(msgStr)
+	if err != nil {
+		return fmt.Errorf("failed to decode message: %v", err)
+	}
+
+	passphrase, err := inputPassword(accountPassphrase)
+	if err != nil {
+		return err
+	}
+	output, proof, err := r.client.SignVRF(acc.Name, passphrase, msg)
+	crypto.Wipe(passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to compute VRF proof: %v", err)
+	}
+	fmt.Println(printPrefix, "VRF output:", r.encodeBytes(output))
+	fmt.Println(printPrefix, "VRF proof:", r.encodeBytes(proof))
+	return nil
+}
+
+func (r *repl) vrfVerify() error {
+	pubStr, err := inputNotBlank(vrfPubKeyMsg)
+	if err != nil {
+		return err
+	}
+	pub, err := r.decodeBytes(pubStr)
+	if err != nil {
+		return fmt.Errorf("failed to decode VRF public key: %v", err)
+	}
+	msgStr, err := inputNotBlank(vrfMessageMsg)
+	if err != nil {
+		return err
+	}
+	msg, err := r.decodeBytes(msgStr)
+	if err != nil {
+		return fmt.Errorf("failed to decode message: %v", err)
+	}
+	proofStr, err := inputNotBlank(vrfProofMsg)
+	if err != nil {
+		return err
+	}
+	proof, err := r.decodeBytes(proofStr)
+	if err != nil {
+		return fmt.Errorf("failed to decode VRF proof: %v", err)
+	}
+
+	output, err := crypto.VerifyVRF(msg, proof, pub)
+	if err != nil {
+		return errors.New("VRF proof is NOT valid")
+	}
+	fmt.Println(printPrefix, "VRF proof is valid, output:", r.encodeBytes(output))
+	return nil
+}
+
+// recipientKey asks for one of the wallet accounts or a public key and returns its key scheme and public key.
+func (r *repl) recipientKey() (accounts.KeyScheme, []byte, error) {
+	query, err := inputNotBlankWithCompletion(recipientKeyMsg, r.accountCompleter(r.client.ListAccounts()))
+	if err != nil {
+		return nil, nil, err
+	}
+	query = strings.TrimSpace(query)
+	if matches := r.client.MatchAccounts(r.client.ListAccounts(), query); len(matches) == 1 {
+		acc, err := r.client.GetAccount(matches[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return acc.Scheme, acc.PubKey, nil
+	}
+
+	pubStr := strings.TrimPrefix(query, "0x")
+	scheme, err := accounts.DetectScheme(pubStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := scheme.ParsePublicKey(pubStr)
+	return scheme, pub, err
+}
+
+func (r *repl) encrypt() error {
+	scheme, pub, err := r.recipientKey()
+	if err != nil {
+		return fmt.Errorf("failed to get recipient public key: %v", err)
+	}
+
+	if len(r.params) > 0 {
+		in := r.params[0]
+		data, err := ioutil.ReadFile(in)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %v", err)
+		}
+		ciphertext, err := scheme.Encrypt(pub, data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt file: %v", err)
+		}
+		out := in + encryptedFileExt
+		if err := writeNewFile(out, ciphertext); err != nil {
+			return fmt.Errorf("failed to write encrypted file: %v", err)
+		}
+		fmt.Printf("%s Encrypted %s to %s address %s in %s \n", printPrefix, in, scheme.Name(), accounts.StringAddress(scheme.Address(pub)), out)
+		return nil
+	}
+
+	msg, err := inputNotBlank(msgTextEncryptMsg)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := scheme.Encrypt(pub, []byte(msg))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt message: %v", err)
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("Encrypted to %s address %s:", scheme.Name(), accounts.StringAddress(scheme.Address(pub))))
+	fmt.Println(hex.EncodeToString(ciphertext))
+	return nil
+}
+
+func (r *repl) decrypt() error {
+	acc, err := r.unlockedAccount()
+	if err != nil {
+		return err
+	}
+
+	if len(r.params) > 0 {
+		in := r.params[0]
+		ciphertext, err := ioutil.ReadFile(in)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %v", err)
+		}
+		data, err := r.client.Decrypt(acc, ciphertext)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt file: %v", err)
+		}
+		defer crypto.Wipe(data)
+
+		out := strings.TrimSuffix(in, encryptedFileExt)
+		if out == in {
+			out = in + decryptedFileExt
+		}
+		if err := writeNewFile(out, data); err != nil {
+			return fmt.Errorf("failed to write decrypted file: %v", err)
+		}
+		fmt.Printf("%s Decrypted %s to %s \n", printPrefix, in, out)
+		return nil
+	}
+
+	ciphertextStr, err := inputNotBlank(msgDecryptMsg)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(ciphertextStr), "0x"))
+	if err != nil {
+		return fmt.Errorf("failed to decode message hex string: %v", err)
+	}
+	msg, err := r.client.Decrypt(acc, ciphertext)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt message: %v", err)
+	}
+	defer crypto.Wipe(msg)
+
+	fmt.Println(printPrefix, "Decrypted message:")
+	fmt.Println(string(msg))
+	return nil
+}
+
+// writeNewFile writes data to a new file at path, readable by the owner only. Existing files are not overwritten.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *repl) contactCompleter(in prompt.Document) []prompt.Suggest {
+	suggests := make([]prompt.Suggest, 0)
+	for _, c := range r.client.ListContacts() {
+		suggests = append(suggests, prompt.Suggest{
+			Text:        c.Name,
+			Description: c.Address,
+		})
+	}
+
+	// contact names may contain spaces, the whole input is matched
+	return prompt.FilterHasPrefix(suggests, strings.TrimLeft(in.TextBeforeCursor(), " "), true)
+}
+
+func (r *repl) addContact() error {
+	name, err := inputNotBlank(contactNameMsg)
+	if err != nil {
+		return err
+	}
+	addr, err := inputNotBlank(contactAddressMsg)
+	if err != nil {
+		return err
+	}
+	note, err := input(contactNoteMsg)
+	if err != nil {
+		return err
+	}
+
+	c, err := r.client.AddContact(name, strings.TrimSpace(addr), note)
+	if err != nil {
+		return fmt.Errorf("failed to add contact: %v", err)
+	}
+	if alias, ok := r.client.AccountByAddress(address.HexToAddress(c.Address)); ok {
+		fmt.Println(printPrefix, fmt.Sprintf("Note: this address belongs to your account `%s`", alias))
+	}
+
+	if err := r.client.StoreContacts(); err != nil {
+		return fmt.Errorf("failed to store contacts: %v", err)
+	}
+
+	fmt.Printf("%s Added contact `%s`, address: %s \n", printPrefix, c.Name, c.Address)
+	return nil
+}
+
+func (r *repl) listContacts() error {
+	lst := r.client.ListContacts()
+	if len(lst) == 0 {
+		fmt.Println(printPrefix, "Address book is empty.")
+		return nil
+	}
+
+	for _, c := range lst {
+		fmt.Println(printPrefix, fmt.Sprintf("%s %s created: %s %s", c.Name, c.Address, c.Created.Format("2006-01-02"), c.Note))
+	}
+	return nil
+}
+
+func (r *repl) removeContact() error {
+	name, err := inputNotBlankWithCompletion(contactNameMsg, r.contactCompleter)
+	if err != nil {
+		return err
+	}
+	if err := r.client.RemoveContact(strings.TrimSpace(name)); err != nil {
+		return fmt.Errorf("failed to remove contact: %v", err)
+	}
+
+	if err := r.client.StoreContacts(); err != nil {
+		return fmt.Errorf("failed to store contacts: %v", err)
+	}
+
+	fmt.Printf("%s Removed contact `%s` \n", printPrefix, name)
+	return nil
+}
+
+func (r *repl) renameContact() error {
+	name, err := inputNotBlankWithCompletion(contactNameMsg, r.contactCompleter)
+	if err != nil {
+		return err
+	}
+	newName, err := inputNotBlank(contactNewNameMsg)
+	if err != nil {
+		return err
+	}
+	if err := r.client.RenameContact(strings.TrimSpace(name), newName); err != nil {
+		return fmt.Errorf("failed to rename contact: %v", err)
+	}
+
+	if err := r.client.StoreContacts(); err != nil {
+		return fmt.Errorf("failed to store contacts: %v", err)
+	}
+
+	fmt.Printf("%s Renamed contact `%s` to `%s` \n", printPrefix, name, newName)
+	return nil
 }
 
 /*
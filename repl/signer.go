@@ -0,0 +1,85 @@
+package repl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/client"
+	"github.com/libonomy/wallet-cli/signer"
+)
+
+// ServeSigner runs the signer daemon: it unlocks the accounts called names, or all accounts if names is empty, and
+// serves their keys on the Unix socket at socketPath until interrupted. The keys are wiped on exit.
+func ServeSigner(c Client, socketPath string, names []string) error {
+	if c.WalletName() == "" {
+		return client.ErrNoWallet
+	}
+	if len(names) == 0 {
+		names = c.ListAccounts()
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("wallet `%s` has no accounts", c.WalletName())
+	}
+
+	r := &repl{client: c}
+	accs := make([]*accounts.Account, 0, len(names))
+	served := make(map[string]*accounts.Account, len(names)) // by hex public key
+	defer func() {
+		for _, acc := range accs {
+			acc.Lock()
+		}
+	}()
+	for _, name := range names {
+		if key, ok := c.HSMKey(name); ok {
+			fmt.Printf("%s Skipping account `%s`, kept in %s \n", printPrefix, name, key)
+			continue
+		}
+		acc, err := c.GetAccount(name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s Unlocking account `%s`, address: %s \n", printPrefix, acc.Name, accounts.StringAddress(acc.Address()))
+		if err := r.unlock(acc); err != nil {
+			return fmt.Errorf("failed to unlock account `%s`: %v", acc.Name, err)
+		}
+		accs = append(accs, acc)
+		served[hex.EncodeToString(acc.PubKey)] = acc
+	}
+	if len(accs) == 0 {
+		return fmt.Errorf("no account to serve")
+	}
+
+	srv, err := signer.Listen(socketPath, c.DaemonSigner(accs...))
+	if err != nil {
+		return err
+	}
+	srv.OnSign = func(pub, msg []byte, err error) {
+		acc, ok := served[hex.EncodeToString(pub)]
+		name := hex.EncodeToString(pub)
+		if ok {
+			name = acc.Name
+		}
+		if err != nil {
+			fmt.Printf("%s Refused to sign %d bytes with `%s`: %v \n", printPrefix, len(msg), name, err)
+			return
+		}
+		fmt.Printf("%s Signed %d bytes with `%s` \n", printPrefix, len(msg), name)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		<-sig
+		_ = srv.Close()
+	}()
+
+	fmt.Printf("%s Signer serving %d keys on %s, press Ctrl-C to stop \n", printPrefix, len(accs), socketPath)
+	err = srv.Serve()
+	fmt.Println(printPrefix, "Signer stopped, keys wiped from memory.")
+	return err
+}
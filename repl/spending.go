@@ -0,0 +1,172 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/spending"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// allowSpending checks the transfer against the spending policy of acc. If it breaks the policy the user may
+// override it once by entering the account passphrase again, which is recorded in the audit log.
+func (r *repl) allowSpending(acc *accounts.Account, recipient address.Address, amount, gasPrice uint64) error {
+	violations := r.client.CheckSpending(acc, recipient, amount, gasPrice)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	fmt.Println(printPrefix, fmt.Sprintf("The transfer breaks the spending policy of `%s`:", acc.Name))
+	for _, v := range violations {
+		fmt.Println(printPrefix, " -", v)
+	}
+	answer, err := yesOrNoQuestion(overrideSpendingMsg)
+	if err != nil {
+		return err
+	}
+	if answer == "n" {
+		return errors.New("the transfer breaks the spending policy")
+	}
+
+	passphrase, err := inputPassword(accountPassphrase)
+	if err != nil {
+		return err
+	}
+	defer crypto.Wipe(passphrase)
+	if err := r.client.OverrideSpending(acc, recipient, amount, gasPrice, passphrase); err != nil {
+		return fmt.Errorf("failed to override spending policy: %v", err)
+	}
+	return nil
+}
+
+func (r *repl) showSpending() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	p, day, week, err := r.client.SpendingPolicy(acc.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get spending policy: %v", err)
+	}
+	if p == nil {
+		p = &spending.Policy{}
+	}
+	fmt.Println(printPrefix, "Spending policy:", p)
+	fmt.Println(printPrefix, "Sent last day: ", day)
+	fmt.Println(printPrefix, "Sent last week:", week)
+	return nil
+}
+
+func (r *repl) setSpending() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	p, _, _, err := r.client.SpendingPolicy(acc.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get spending policy: %v", err)
+	}
+	exists := p != nil
+	if !exists {
+		p = &spending.Policy{}
+	}
+
+	next := &spending.Policy{}
+	for _, limit := range []struct {
+		msg   string
+		value uint64
+		dest  *uint64
+	}{
+		{maxAmountMsg, p.MaxAmount, &next.MaxAmount},
+		{dailyCapMsg, p.DailyCap, &next.DailyCap},
+		{weeklyCapMsg, p.WeeklyCap, &next.WeeklyCap},
+		{maxGasPriceMsg, p.MaxGasPrice, &next.MaxGasPrice},
+	} {
+		s, err := input(fmt.Sprintf(limit.msg, limit.value))
+		if err != nil {
+			return err
+		}
+		s = strings.TrimSpace(s)
+		if s == "" {
+			*limit.dest = limit.value
+			continue
+		}
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid limit: %v", err)
+		}
+		*limit.dest = v
+	}
+	allow, err := input(fmt.Sprintf(allowListMsg, strings.Join(p.Allow, " ")))
+	if err != nil {
+		return err
+	}
+	next.Allow = recipientList(allow, p.Allow)
+	deny, err := input(fmt.Sprintf(denyListMsg, strings.Join(p.Deny, " ")))
+	if err != nil {
+		return err
+	}
+	next.Deny = recipientList(deny, p.Deny)
+	if err := next.Validate(); err != nil {
+		return fmt.Errorf("invalid spending policy: %v", err)
+	}
+
+	fmt.Println(printPrefix, "New spending policy:", next)
+	answer, err := yesOrNoQuestion(confirmSpendingMsg)
+	if err != nil || answer == "n" {
+		return err
+	}
+	return r.storeSpending(acc, next, exists)
+}
+
+func (r *repl) removeSpending() error {
+	acc, err := r.currentAccount()
+	if err != nil {
+		return err
+	}
+
+	p, _, _, err := r.client.SpendingPolicy(acc.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get spending policy: %v", err)
+	}
+	if p == nil {
+		fmt.Println(printPrefix, fmt.Sprintf("Account `%s` has no spending policy", acc.Name))
+		return nil
+	}
+	return r.storeSpending(acc, &spending.Policy{}, true)
+}
+
+// storeSpending sets the spending policy of acc, asking for the account passphrase to change an existing policy.
+func (r *repl) storeSpending(acc *accounts.Account, p *spending.Policy, exists bool) error {
+	var passphrase []byte
+	if exists {
+		var err error
+		if passphrase, err = inputPassword(accountPassphrase); err != nil {
+			return err
+		}
+		defer crypto.Wipe(passphrase)
+	}
+	if err := r.client.SetSpendingPolicy(acc.Name, p, passphrase); err != nil {
+		return fmt.Errorf("failed to set spending policy: %v", err)
+	}
+	fmt.Println(printPrefix, fmt.Sprintf("Spending policy of account `%s`: %s", acc.Name, p))
+	return nil
+}
+
+// recipientList parses space or comma separated addresses, keeping current if s is blank and clearing it if s is -.
+func recipientList(s string, current []string) []string {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return current
+	case "-":
+		return nil
+	}
+	return strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' })
+}
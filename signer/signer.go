@@ -0,0 +1,77 @@
+// Package signer separates signing from the wallet process. A Signer signs messages with the key matching a
+// public key; Local signs with unlocked accounts in memory and Client asks a signer daemon serving Local over a
+// Unix socket, so the process talking to the network never holds private keys.
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/libonomy/wallet-cli/accounts"
+)
+
+// Key is a public key a Signer can sign with.
+type Key struct {
+	Name   string `json:"name"`
+	Scheme string `json:"scheme"`
+	PubKey string `json:"pubkey"` // hex encoded
+}
+
+// Signer signs messages with the private keys it holds.
+type Signer interface {
+	// PublicKeys lists the keys available for signing.
+	PublicKeys() ([]Key, error)
+	// Sign signs msg with the key matching the public key pub, using the key scheme of the key.
+	Sign(pub, msg []byte) ([]byte, error)
+}
+
+// Local signs with unlocked accounts held in memory.
+type Local struct {
+	mu       sync.Mutex
+	accounts []*accounts.Account
+}
+
+// NewLocal returns a signer of the unlocked accounts accs.
+func NewLocal(accs ...*accounts.Account) *Local {
+	return &Local{accounts: accs}
+}
+
+// PublicKeys returns the keys of the accounts still unlocked.
+func (l *Local) PublicKeys() ([]Key, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := make([]Key, 0, len(l.accounts))
+	for _, acc := range l.accounts {
+		if acc.IsLocked() {
+			continue
+		}
+		keys = append(keys, Key{Name: acc.Name, Scheme: acc.Scheme.Name(), PubKey: hex.EncodeToString(acc.PubKey)})
+	}
+	return keys, nil
+}
+
+// Sign signs msg with the account of public key pub.
+func (l *Local) Sign(pub, msg []byte) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, acc := range l.accounts {
+		if bytes.Equal(acc.PubKey, pub) {
+			return acc.Sign(msg)
+		}
+	}
+	return nil, fmt.Errorf("no key for public key %x", pub)
+}
+
+// Lock wipes the private keys of all accounts.
+func (l *Local) Lock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, acc := range l.accounts {
+		acc.Lock()
+	}
+}
@@ -0,0 +1,87 @@
+package signer
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	crypto.DefaultCypherParams.N = 1024
+	os.Exit(m.Run())
+}
+
+func TestSocketSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := accounts.Store{}
+	alice, err := s.CreateAccount("alice", "ed25519", 0, []byte("beagles"), crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	bob, err := s.CreateAccount("bob", "secp256k1", 0, []byte("beagles"), crypto.DefaultCypherParams)
+	assert.NoError(t, err)
+	local := NewLocal(alice, bob)
+
+	path := DefaultSocketPath(dir)
+	srv, err := Listen(path, local)
+	assert.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- srv.Serve() }()
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = Listen(path, local)
+	assert.Error(t, err, "socket in use")
+
+	c := NewClient(path)
+	keys, err := c.PublicKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{
+		{Name: "alice", Scheme: "ed25519", PubKey: hex.EncodeToString(alice.PubKey)},
+		{Name: "bob", Scheme: "secp256k1", PubKey: hex.EncodeToString(bob.PubKey)},
+	}, keys)
+
+	msg := []byte("transaction")
+	for _, acc := range []*accounts.Account{alice, bob} {
+		sig, err := c.Sign(acc.PubKey, msg)
+		assert.NoError(t, err)
+		assert.True(t, acc.Verify(msg, sig))
+	}
+
+	_, err = c.Sign(make([]byte, 32), msg)
+	assert.Error(t, err, "unknown key")
+
+	bob.Lock()
+	keys, err = c.PublicKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	_, err = c.Sign(bob.PubKey, msg)
+	assert.Error(t, err, "locked key")
+
+	assert.NoError(t, srv.Close())
+	assert.NoError(t, <-done)
+	_, err = c.PublicKeys()
+	assert.Error(t, err)
+
+	local.Lock()
+	assert.True(t, alice.IsLocked())
+}
+
+func TestListenPermissions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.Chmod(dir, 0755))
+	_, err = Listen(filepath.Join(dir, socketFileName), NewLocal())
+	assert.Error(t, err, "directory readable by other users")
+}
@@ -0,0 +1,205 @@
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	socketDir      = "signer"
+	socketFileName = "signer.sock"
+
+	methodListPubKeys = "list-pubkeys"
+	methodSign        = "sign"
+
+	maxRequestSize = 1 << 20
+	callTimeout    = 30 * time.Second
+)
+
+// request is a single call to the signer daemon, sent as JSON on a new connection.
+type request struct {
+	Method  string `json:"method"`
+	PubKey  string `json:"pubkey,omitempty"`  // hex encoded
+	Message string `json:"message,omitempty"` // hex encoded
+}
+
+type response struct {
+	Keys      []Key  `json:"keys,omitempty"`
+	Signature string `json:"signature,omitempty"` // hex encoded
+	Error     string `json:"error,omitempty"`
+}
+
+// DefaultSocketPath returns the path of the signer daemon socket of datadir.
+func DefaultSocketPath(datadir string) string {
+	return filepath.Join(datadir, socketDir, socketFileName)
+}
+
+// Server serves the keys of a Signer on a Unix socket.
+type Server struct {
+	signer Signer
+	ln     net.Listener
+	closed int32
+
+	// OnSign is called after each signing request if set.
+	OnSign func(pub, msg []byte, err error)
+}
+
+// Listen creates the Unix socket at path, accessible only by the current user. The directory of the socket is
+// created with mode 0700 and must not be accessible to other users.
+func Listen(path string, s Signer) (*Server, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("socket directory %s is accessible to other users (mode %v)", dir, info.Mode().Perm())
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a signer is already listening on %s", path)
+		}
+		// stale socket of a signer that did not exit cleanly
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return &Server{signer: s, ln: ln}, nil
+}
+
+// Serve answers requests until Close is called.
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if atomic.LoadInt32(&s.closed) == 1 {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops serving and removes the socket.
+func (s *Server) Close() error {
+	atomic.StoreInt32(&s.closed, 1)
+	return s.ln.Close()
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(callTimeout))
+
+	req := &request{}
+	if err := json.NewDecoder(io.LimitReader(conn, maxRequestSize)).Decode(req); err != nil {
+		_ = json.NewEncoder(conn).Encode(&response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	_ = json.NewEncoder(conn).Encode(s.serve(req))
+}
+
+func (s *Server) serve(req *request) *response {
+	switch req.Method {
+	case methodListPubKeys:
+		keys, err := s.signer.PublicKeys()
+		if err != nil {
+			return &response{Error: err.Error()}
+		}
+		return &response{Keys: keys}
+	case methodSign:
+		pub, err := hex.DecodeString(req.PubKey)
+		if err != nil {
+			return &response{Error: "invalid public key"}
+		}
+		msg, err := hex.DecodeString(req.Message)
+		if err != nil {
+			return &response{Error: "invalid message"}
+		}
+		sig, err := s.signer.Sign(pub, msg)
+		if s.OnSign != nil {
+			s.OnSign(pub, msg, err)
+		}
+		if err != nil {
+			return &response{Error: err.Error()}
+		}
+		return &response{Signature: hex.EncodeToString(sig)}
+	default:
+		return &response{Error: fmt.Sprintf("unknown method `%s`", req.Method)}
+	}
+}
+
+// Client is a Signer asking the signer daemon listening on a Unix socket.
+type Client struct {
+	path string
+}
+
+// NewClient returns a client of the signer daemon listening on the socket at path.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Path returns the path of the daemon socket.
+func (c *Client) Path() string {
+	return c.path
+}
+
+func (c *Client) call(req *request) (*response, error) {
+	conn, err := net.DialTimeout("unix", c.path, callTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach the signer at %s: %v", c.path, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(callTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	resp := &response{}
+	if err := json.NewDecoder(io.LimitReader(conn, maxRequestSize)).Decode(resp); err != nil {
+		return nil, fmt.Errorf("invalid signer response: %v", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("signer: %s", resp.Error)
+	}
+	return resp, nil
+}
+
+// PublicKeys returns the keys unlocked in the daemon.
+func (c *Client) PublicKeys() ([]Key, error) {
+	resp, err := c.call(&request{Method: methodListPubKeys})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// Sign asks the daemon to sign msg with the key of pub.
+func (c *Client) Sign(pub, msg []byte) ([]byte, error) {
+	resp, err := c.call(&request{Method: methodSign, PubKey: hex.EncodeToString(pub), Message: hex.EncodeToString(msg)})
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(resp.Signature)
+}
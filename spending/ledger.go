@@ -0,0 +1,104 @@
+package spending
+
+import (
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// Record is a sent transfer counted against the spending caps.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Amount    uint64    `json:"amount"`
+	Recipient string    `json:"recipient"`
+	TxID      string    `json:"txId,omitempty"`
+}
+
+// Ledger holds the policies of accounts and their transfers of the last week, by account address. It is stored in
+// the wallet file, so the policies are authenticated with the wallet contents.
+type Ledger struct {
+	Policies map[string]*Policy  `json:"policies"`
+	History  map[string][]Record `json:"history"`
+
+	now func() time.Time
+}
+
+// NewLedger returns an empty ledger. Stored ledgers are decoded into it.
+func NewLedger() *Ledger {
+	return &Ledger{Policies: make(map[string]*Policy), History: make(map[string][]Record), now: time.Now}
+}
+
+func key(addr address.Address) string {
+	return strings.ToLower(accounts.StringAddress(addr))
+}
+
+// Policy returns the policy of the account with address addr, if any.
+func (l *Ledger) Policy(addr address.Address) (*Policy, bool) {
+	p, ok := l.Policies[key(addr)]
+	return p, ok
+}
+
+// SetPolicy sets the policy of the account with address addr. An empty policy removes it.
+func (l *Ledger) SetPolicy(addr address.Address, p *Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if p.IsEmpty() {
+		delete(l.Policies, key(addr))
+		return nil
+	}
+	if l.Policies == nil {
+		l.Policies = make(map[string]*Policy)
+	}
+	l.Policies[key(addr)] = p
+	return nil
+}
+
+// Spent returns the amount sent by the account with address addr over the last window.
+func (l *Ledger) Spent(addr address.Address, window time.Duration) uint64 {
+	since := l.now().Add(-window)
+	total := uint64(0)
+	for _, r := range l.History[key(addr)] {
+		if r.Time.After(since) {
+			total = add(total, r.Amount)
+		}
+	}
+	return total
+}
+
+// Check returns the rules of the sender policy t breaks, if any.
+func (l *Ledger) Check(t Transfer) []string {
+	p, ok := l.Policy(t.From)
+	if !ok {
+		return nil
+	}
+	return p.Check(t, l.Spent(t.From, Day), l.Spent(t.From, Week))
+}
+
+// Record adds the transfer t to the history of its sender and drops records older than a week.
+func (l *Ledger) Record(t Transfer, txID string) {
+	now := l.now()
+	records := []Record{{Time: now.UTC(), Amount: t.Amount, Recipient: accounts.StringAddress(t.To), TxID: txID}}
+	for _, r := range l.History[key(t.From)] {
+		if r.Time.After(now.Add(-Week)) {
+			records = append(records, r)
+		}
+	}
+	if l.History == nil {
+		l.History = make(map[string][]Record)
+	}
+	l.History[key(t.From)] = records
+}
+
+// Cancel removes the last record of t without a transaction ID, added before sending t, e.g. when sending failed.
+func (l *Ledger) Cancel(t Transfer) {
+	records := l.History[key(t.From)]
+	for i, r := range records {
+		if r.TxID == "" && r.Amount == t.Amount && r.Recipient == accounts.StringAddress(t.To) {
+			l.History[key(t.From)] = append(records[:i:i], records[i+1:]...)
+			return
+		}
+	}
+}
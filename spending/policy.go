@@ -0,0 +1,122 @@
+// Package spending limits the transfers of accounts. A policy caps the amount per transaction and the amounts sent
+// over the last day and week, restricts recipients and the gas price. Transfers are tracked in a ledger file to
+// enforce the caps.
+package spending
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// Spending cap windows.
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// Policy limits the transfers of an account. Zero values and empty lists disable a rule.
+type Policy struct {
+	MaxAmount   uint64   `json:"maxAmount,omitempty"`   // per transaction
+	DailyCap    uint64   `json:"dailyCap,omitempty"`    // amount sent over the last 24 hours
+	WeeklyCap   uint64   `json:"weeklyCap,omitempty"`   // amount sent over the last 7 days
+	Allow       []string `json:"allow,omitempty"`       // allowed recipient addresses, any if empty
+	Deny        []string `json:"deny,omitempty"`        // denied recipient addresses
+	MaxGasPrice uint64   `json:"maxGasPrice,omitempty"` // per gas unit
+}
+
+// Transfer is a transfer checked against a policy.
+type Transfer struct {
+	From     address.Address
+	To       address.Address
+	Amount   uint64
+	GasPrice uint64
+}
+
+// Validate checks the recipient lists hold addresses.
+func (p *Policy) Validate() error {
+	for _, lst := range [][]string{p.Allow, p.Deny} {
+		for _, a := range lst {
+			if !address.IsHexAddress(a) {
+				return fmt.Errorf("invalid recipient address `%s`", a)
+			}
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the policy has no rule.
+func (p *Policy) IsEmpty() bool {
+	return p.MaxAmount == 0 && p.DailyCap == 0 && p.WeeklyCap == 0 && len(p.Allow) == 0 && len(p.Deny) == 0 &&
+		p.MaxGasPrice == 0
+}
+
+// Check returns the rules t breaks, given the amounts already sent over the last day and week.
+func (p *Policy) Check(t Transfer, spentDay, spentWeek uint64) []string {
+	var violations []string
+	if p.MaxAmount > 0 && t.Amount > p.MaxAmount {
+		violations = append(violations, fmt.Sprintf("amount %d exceeds the limit of %d per transaction", t.Amount, p.MaxAmount))
+	}
+	if p.DailyCap > 0 && add(spentDay, t.Amount) > p.DailyCap {
+		violations = append(violations, fmt.Sprintf("%d already sent over the last day, the daily cap is %d", spentDay, p.DailyCap))
+	}
+	if p.WeeklyCap > 0 && add(spentWeek, t.Amount) > p.WeeklyCap {
+		violations = append(violations, fmt.Sprintf("%d already sent over the last week, the weekly cap is %d", spentWeek, p.WeeklyCap))
+	}
+	if contains(p.Deny, t.To) {
+		violations = append(violations, fmt.Sprintf("recipient %s is denied", t.To.Hex()))
+	}
+	if len(p.Allow) > 0 && !contains(p.Allow, t.To) {
+		violations = append(violations, fmt.Sprintf("recipient %s is not allowed", t.To.Hex()))
+	}
+	if p.MaxGasPrice > 0 && t.GasPrice > p.MaxGasPrice {
+		violations = append(violations, fmt.Sprintf("gas price %d exceeds the limit of %d", t.GasPrice, p.MaxGasPrice))
+	}
+	return violations
+}
+
+// String returns the rules of the policy.
+func (p *Policy) String() string {
+	rules := make([]string, 0)
+	if p.MaxAmount > 0 {
+		rules = append(rules, fmt.Sprintf("max amount %d", p.MaxAmount))
+	}
+	if p.DailyCap > 0 {
+		rules = append(rules, fmt.Sprintf("daily cap %d", p.DailyCap))
+	}
+	if p.WeeklyCap > 0 {
+		rules = append(rules, fmt.Sprintf("weekly cap %d", p.WeeklyCap))
+	}
+	if len(p.Allow) > 0 {
+		rules = append(rules, fmt.Sprintf("allowed recipients %s", strings.Join(p.Allow, ", ")))
+	}
+	if len(p.Deny) > 0 {
+		rules = append(rules, fmt.Sprintf("denied recipients %s", strings.Join(p.Deny, ", ")))
+	}
+	if p.MaxGasPrice > 0 {
+		rules = append(rules, fmt.Sprintf("max gas price %d", p.MaxGasPrice))
+	}
+	if len(rules) == 0 {
+		return "no limits"
+	}
+	return strings.Join(rules, "; ")
+}
+
+func contains(lst []string, addr address.Address) bool {
+	for _, a := range lst {
+		if address.HexToAddress(a) == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// add returns a + b, saturating instead of overflowing.
+func add(a, b uint64) uint64 {
+	if a+b < a {
+		return ^uint64(0)
+	}
+	return a + b
+}
@@ -0,0 +1,81 @@
+package spending
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/libonomy/wallet-cli/wallet/address"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	from  = address.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob   = address.HexToAddress("0x2222222222222222222222222222222222222222")
+	carol = address.HexToAddress("0x3333333333333333333333333333333333333333")
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p := &Policy{MaxAmount: 100, DailyCap: 150, WeeklyCap: 500, MaxGasPrice: 2}
+	assert.Empty(t, p.Check(Transfer{From: from, To: bob, Amount: 100, GasPrice: 2}, 50, 400))
+	assert.Len(t, p.Check(Transfer{From: from, To: bob, Amount: 101, GasPrice: 2}, 0, 0), 1)
+	assert.Len(t, p.Check(Transfer{From: from, To: bob, Amount: 100, GasPrice: 2}, 51, 401), 2)
+	assert.Len(t, p.Check(Transfer{From: from, To: bob, Amount: 1, GasPrice: 3}, 0, 0), 1)
+	assert.Len(t, p.Check(Transfer{From: from, To: bob, Amount: 1}, ^uint64(0), 0), 1, "no overflow")
+
+	p = &Policy{Allow: []string{bob.Hex()}, Deny: []string{carol.Hex()}}
+	assert.NoError(t, p.Validate())
+	assert.Empty(t, p.Check(Transfer{From: from, To: bob}, 0, 0))
+	assert.Len(t, p.Check(Transfer{From: from, To: carol}, 0, 0), 2)
+
+	assert.Error(t, (&Policy{Deny: []string{"bob"}}).Validate())
+	assert.True(t, (&Policy{}).IsEmpty())
+	assert.Equal(t, "no limits", (&Policy{}).String())
+}
+
+func TestLedger(t *testing.T) {
+	l := NewLedger()
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	tx := Transfer{From: from, To: bob, Amount: 60}
+	assert.Empty(t, l.Check(tx), "no policy")
+	assert.NoError(t, l.SetPolicy(from, &Policy{DailyCap: 100, WeeklyCap: 150}))
+
+	l.Record(tx, "tx1")
+	assert.Empty(t, l.Check(Transfer{From: from, To: bob, Amount: 40}))
+	assert.Len(t, l.Check(tx), 1, "daily cap")
+
+	now = now.Add(Day)
+	l.Record(tx, "tx2")
+	assert.Equal(t, uint64(60), l.Spent(from, Day))
+	assert.Equal(t, uint64(120), l.Spent(from, Week))
+	assert.Len(t, l.Check(Transfer{From: from, To: bob, Amount: 31}), 1, "weekly cap")
+
+	// transfers are recorded before they are sent and cancelled if sending fails
+	l.Record(Transfer{From: from, To: carol, Amount: 5}, "")
+	assert.Equal(t, uint64(125), l.Spent(from, Week))
+	l.Cancel(Transfer{From: from, To: carol, Amount: 5})
+	assert.Equal(t, uint64(120), l.Spent(from, Week))
+	l.Cancel(tx)
+	assert.Equal(t, uint64(120), l.Spent(from, Week), "sent transfers are not cancelled")
+
+	data, err := json.Marshal(l)
+	assert.NoError(t, err)
+	loaded := NewLedger()
+	assert.NoError(t, json.Unmarshal(data, loaded))
+	loaded.now = l.now
+	p, ok := loaded.Policy(from)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), p.DailyCap)
+	assert.Equal(t, uint64(120), loaded.Spent(from, Week))
+
+	// records older than a week are dropped
+	now = now.Add(Week)
+	loaded.Record(Transfer{From: from, To: bob, Amount: 1}, "tx3")
+	assert.Len(t, loaded.History[key(from)], 1)
+
+	assert.NoError(t, loaded.SetPolicy(from, &Policy{}))
+	_, ok = loaded.Policy(from)
+	assert.False(t, ok, "empty policy removes it")
+}
@@ -0,0 +1,45 @@
+package typeddata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format returns a human readable rendering of the domain and message fields, for users to review before signing.
+// String values are quoted so hidden whitespace and control characters are visible.
+func (td *TypedData) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Domain: %s (version %s, network %d)\n", strconv.Quote(td.Domain.Name),
+		strconv.Quote(td.Domain.Version), td.Domain.NetworkID)
+	fmt.Fprintf(&b, "%s:\n", td.PrimaryType)
+	td.formatStruct(&b, td.PrimaryType, td.Message, 1)
+	return b.String()
+}
+
+func (td *TypedData) formatStruct(b *strings.Builder, typ string, v map[string]interface{}, depth int) {
+	for _, f := range td.Types[typ] {
+		td.formatValue(b, f.Name, f.Type, v[f.Name], depth)
+	}
+}
+
+func (td *TypedData) formatValue(b *strings.Builder, name, typ string, v interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch {
+	case strings.HasSuffix(typ, "[]"):
+		items, _ := v.([]interface{})
+		fmt.Fprintf(b, "%s%s (%s, %d items):\n", indent, name, typ, len(items))
+		for i, item := range items {
+			td.formatValue(b, fmt.Sprintf("[%d]", i), strings.TrimSuffix(typ, "[]"), item, depth+1)
+		}
+	case td.Types[typ] != nil:
+		fmt.Fprintf(b, "%s%s (%s):\n", indent, name, typ)
+		m, _ := v.(map[string]interface{})
+		td.formatStruct(b, typ, m, depth+1)
+	case typ == "string":
+		s, _ := v.(string)
+		fmt.Fprintf(b, "%s%s: %s\n", indent, name, strconv.Quote(s))
+	default:
+		fmt.Fprintf(b, "%s%s: %v\n", indent, name, v)
+	}
+}
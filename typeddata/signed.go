@@ -0,0 +1,75 @@
+package typeddata
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/accounts"
+)
+
+// Signed is typed data along with its signature and the signer key, as returned to the requesting service.
+type Signed struct {
+	Data      *TypedData `json:"data"`
+	Scheme    string     `json:"scheme"`
+	Signer    string     `json:"signer"` // address of the signing account
+	PubKey    string     `json:"pubkey"`
+	Signature string     `json:"signature"`
+}
+
+// Sign signs the typed data digest as acc with sign, e.g. WalletBE.Sign.
+func Sign(td *TypedData, acc *accounts.Account, sign func(*accounts.Account, []byte) ([]byte, error)) (*Signed, error) {
+	digest, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign(acc, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signed{
+		Data:      td,
+		Scheme:    acc.Scheme.Name(),
+		Signer:    accounts.StringAddress(acc.Address()),
+		PubKey:    acc.Scheme.EncodeKey(acc.PubKey),
+		Signature: hex.EncodeToString(sig),
+	}, nil
+}
+
+// Verify checks that s is a valid signature of typed data signed for domain. The signer address must match the
+// public key; callers should then check the address is the one they expect.
+func (s *Signed) Verify(domain Domain) error {
+	if s.Data == nil {
+		return fmt.Errorf("missing typed data")
+	}
+	if s.Data.Domain != domain {
+		return fmt.Errorf("signed for domain %s %s network %d, expected %s %s network %d",
+			s.Data.Domain.Name, s.Data.Domain.Version, s.Data.Domain.NetworkID, domain.Name, domain.Version, domain.NetworkID)
+	}
+
+	scheme, err := accounts.GetScheme(s.Scheme)
+	if err != nil {
+		return err
+	}
+	pub, err := scheme.ParsePublicKey(strings.TrimPrefix(s.PubKey, "0x"))
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	if !strings.EqualFold(accounts.StringAddress(scheme.Address(pub)), s.Signer) {
+		return fmt.Errorf("signer address %s does not match the public key", s.Signer)
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(s.Signature, "0x"))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	digest, err := s.Data.Hash()
+	if err != nil {
+		return err
+	}
+	if !scheme.Verify(pub, digest, sig) {
+		return fmt.Errorf("signature is not valid")
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+// Package typeddata signs and verifies structured data. Like EIP-712, a typed JSON message is hashed with a
+// canonical encoding together with a domain naming the application, its version and the network, so a signature
+// cannot be replayed by another application or on another network.
+package typeddata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/libonomy/wallet-cli/wallet/address"
+)
+
+// domainType is the type of Domain, it cannot be redefined by the typed data.
+const domainType = "LibonomyDomain(string name,string version,int64 networkId)"
+
+// digestPrefix separates typed data digests from transactions and other signed messages.
+var digestPrefix = []byte{0x19, 0x01}
+
+var typeNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// basicTypes are the field types encoded as values.
+var basicTypes = map[string]bool{"string": true, "bytes": true, "bool": true, "address": true, "uint64": true, "int64": true}
+
+// Domain identifies the application and network a message is signed for.
+type Domain struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	NetworkID int8   `json:"networkId"`
+}
+
+// Field is a named and typed member of a struct type. Types are basic types, struct types or arrays of them,
+// written with a [] suffix.
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is a message of type PrimaryType signed for Domain. Types defines the struct types.
+type TypedData struct {
+	Types       map[string][]Field     `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      Domain                 `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// UnmarshalJSON decodes typed data keeping numbers as json.Number, so 64 bit integers do not lose precision.
+func (td *TypedData) UnmarshalJSON(data []byte) error {
+	type typedData TypedData
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode((*typedData)(td))
+}
+
+// Parse decodes and validates typed data.
+func Parse(data []byte) (*TypedData, error) {
+	td := &TypedData{}
+	if err := json.Unmarshal(data, td); err != nil {
+		return nil, err
+	}
+	if err := td.Validate(); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// Validate checks the type definitions and that the message matches the primary type.
+func (td *TypedData) Validate() error {
+	if td.Domain.Name == "" {
+		return fmt.Errorf("missing domain name")
+	}
+	for name, fields := range td.Types {
+		if !typeNameRegexp.MatchString(name) || basicTypes[name] {
+			return fmt.Errorf("invalid type name `%s`", name)
+		}
+		seen := make(map[string]bool, len(fields))
+		for _, f := range fields {
+			if !typeNameRegexp.MatchString(f.Name) || seen[f.Name] {
+				return fmt.Errorf("invalid or duplicate field `%s` of type %s", f.Name, name)
+			}
+			seen[f.Name] = true
+			if t := strings.TrimSuffix(f.Type, "[]"); !basicTypes[t] && td.Types[t] == nil {
+				return fmt.Errorf("unknown type %s of field %s.%s", f.Type, name, f.Name)
+			}
+		}
+	}
+	if td.Types[td.PrimaryType] == nil {
+		return fmt.Errorf("unknown primary type `%s`", td.PrimaryType)
+	}
+	if err := td.checkCycles(td.PrimaryType, nil); err != nil {
+		return err
+	}
+
+	_, err := td.hashStruct(td.PrimaryType, td.Message)
+	return err
+}
+
+// checkCycles rejects recursive types, which cannot be encoded.
+func (td *TypedData) checkCycles(typ string, path []string) error {
+	for _, p := range path {
+		if p == typ {
+			return fmt.Errorf("recursive type %s", typ)
+		}
+	}
+	for _, f := range td.Types[typ] {
+		if t := strings.TrimSuffix(f.Type, "[]"); td.Types[t] != nil {
+			if err := td.checkCycles(t, append(path, typ)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Hash returns the digest signed for the typed data, SHA3-256(0x19 0x01 || domain hash || message hash).
+func (td *TypedData) Hash() ([]byte, error) {
+	if err := td.Validate(); err != nil {
+		return nil, err
+	}
+	message, err := td.hashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sha256(digestPrefix, td.Domain.hash(), message), nil
+}
+
+func (d Domain) hash() []byte {
+	return crypto.Sha256(crypto.Sha256([]byte(domainType)), crypto.Sha256([]byte(d.Name)),
+		crypto.Sha256([]byte(d.Version)), intWord(int64(d.NetworkID)))
+}
+
+// EncodeType returns the canonical type string of typ, followed by the struct types it references sorted by name.
+func (td *TypedData) EncodeType(typ string) string {
+	deps := make(map[string]bool)
+	td.dependencies(typ, deps)
+	delete(deps, typ)
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range append([]string{typ}, names...) {
+		b.WriteString(name)
+		b.WriteByte('(')
+		for i, f := range td.Types[name] {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(f.Type + " " + f.Name)
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+func (td *TypedData) dependencies(typ string, deps map[string]bool) {
+	if deps[typ] || td.Types[typ] == nil {
+		return
+	}
+	deps[typ] = true
+	for _, f := range td.Types[typ] {
+		td.dependencies(strings.TrimSuffix(f.Type, "[]"), deps)
+	}
+}
+
+// hashStruct returns SHA3-256(type hash || encoded fields) of the struct value v of type typ.
+func (td *TypedData) hashStruct(typ string, v map[string]interface{}) ([]byte, error) {
+	fields := td.Types[typ]
+	if len(v) != len(fields) {
+		return nil, fmt.Errorf("%s expects %d fields, got %d", typ, len(fields), len(v))
+	}
+
+	words := [][]byte{crypto.Sha256([]byte(td.EncodeType(typ)))}
+	for _, f := range fields {
+		value, ok := v[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing field %s.%s", typ, f.Name)
+		}
+		word, err := td.encodeValue(f.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %v", typ, f.Name, err)
+		}
+		words = append(words, word)
+	}
+	return crypto.Sha256(words...), nil
+}
+
+// encodeValue returns the 32 byte encoding of value v of type typ.
+func (td *TypedData) encodeValue(typ string, v interface{}) ([]byte, error) {
+	if strings.HasSuffix(typ, "[]") {
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array")
+		}
+		words := make([][]byte, len(items))
+		for i, item := range items {
+			word, err := td.encodeValue(strings.TrimSuffix(typ, "[]"), item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %v", i, err)
+			}
+			words[i] = word
+		}
+		return crypto.Sha256(words...), nil
+	}
+
+	if td.Types[typ] != nil {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected %s object", typ)
+		}
+		return td.hashStruct(typ, m)
+	}
+
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string")
+		}
+		return crypto.Sha256([]byte(s)), nil
+	case "bytes":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected hex string")
+		}
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Sha256(b), nil
+	case "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool")
+		}
+		if b {
+			return intWord(1), nil
+		}
+		return intWord(0), nil
+	case "address":
+		s, ok := v.(string)
+		if !ok || !address.IsHexAddress(s) {
+			return nil, fmt.Errorf("expected hex address")
+		}
+		word := make([]byte, 32)
+		copy(word[12:], address.HexToAddress(s).Bytes())
+		return word, nil
+	case "uint64":
+		n, err := strconv.ParseUint(numberString(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected uint64")
+		}
+		word := make([]byte, 32)
+		binary.BigEndian.PutUint64(word[24:], n)
+		return word, nil
+	case "int64":
+		n, err := strconv.ParseInt(numberString(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected int64")
+		}
+		return intWord(n), nil
+	default:
+		return nil, fmt.Errorf("unknown type %s", typ)
+	}
+}
+
+// intWord returns n as a 32 byte big endian two's complement word.
+func intWord(n int64) []byte {
+	word := make([]byte, 32)
+	if n < 0 {
+		for i := range word[:24] {
+			word[i] = 0xff
+		}
+	}
+	binary.BigEndian.PutUint64(word[24:], uint64(n))
+	return word
+}
+
+// numberString returns the decimal representation of an integer given as a JSON number, a string or a Go number.
+func numberString(v interface{}) string {
+	switch n := v.(type) {
+	case json.Number:
+		return n.String()
+	case string:
+		return n
+	case float64:
+		if n != math.Trunc(n) {
+			return ""
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(n)
+	default:
+		return ""
+	}
+}
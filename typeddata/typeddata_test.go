@@ -0,0 +1,137 @@
+package typeddata
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/libonomy/wallet-cli/accounts"
+	"github.com/libonomy/wallet-cli/os/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(acc *accounts.Account, msg []byte) ([]byte, error) {
+	return acc.Sign(msg)
+}
+
+const orderJSON = `{
+  "types": {
+    "Order": [
+      {"name": "item", "type": "string"},
+      {"name": "quantity", "type": "uint64"},
+      {"name": "buyer", "type": "Person"},
+      {"name": "tags", "type": "string[]"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ]
+  },
+  "primaryType": "Order",
+  "domain": {"name": "Libonomy Shop", "version": "1", "networkId": 1},
+  "message": {
+    "item": "coffee",
+    "quantity": 18446744073709551615,
+    "buyer": {"name": "alice", "wallet": "0x00000000000000000000000000000000000000aa"},
+    "tags": ["hot", "large"]
+  }
+}`
+
+var shopDomain = Domain{Name: "Libonomy Shop", Version: "1", NetworkID: 1}
+
+func TestMain(m *testing.M) {
+	crypto.DefaultCypherParams.N = 1024
+	os.Exit(m.Run())
+}
+
+func TestEncodeType(t *testing.T) {
+	td, err := Parse([]byte(orderJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, "Order(string item,uint64 quantity,Person buyer,string[] tags)Person(string name,address wallet)", td.EncodeType("Order"))
+	assert.Equal(t, "Person(string name,address wallet)", td.EncodeType("Person"))
+}
+
+func TestHash(t *testing.T) {
+	td, err := Parse([]byte(orderJSON))
+	assert.NoError(t, err)
+	digest, err := td.Hash()
+	assert.NoError(t, err)
+	assert.Len(t, digest, 32)
+
+	// the encoding does not depend on the JSON key order
+	reordered, err := Parse([]byte(strings.Replace(orderJSON, `"item": "coffee",
+    "quantity": 18446744073709551615,`, `"quantity": 18446744073709551615,
+    "item": "coffee",`, 1)))
+	assert.NoError(t, err)
+	same, err := reordered.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, digest, same)
+
+	// 64 bit integers keep their precision
+	other, err := Parse([]byte(strings.Replace(orderJSON, "18446744073709551615", "18446744073709551614", 1)))
+	assert.NoError(t, err)
+	otherDigest, err := other.Hash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, otherDigest)
+
+	other.Domain.NetworkID = 2
+	otherDigest, err = other.Hash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, otherDigest)
+}
+
+func TestValidate(t *testing.T) {
+	for name, replace := range map[string][2]string{
+		"missing field":  {`"item": "coffee",`, ``},
+		"extra field":    {`"item": "coffee",`, `"item": "coffee", "note": "x",`},
+		"wrong type":     {`"item": "coffee"`, `"item": 7`},
+		"unknown type":   {`"type": "Person"}`, `"type": "Human"}`},
+		"bad address":    {`0x00000000000000000000000000000000000000aa`, `0xaa`},
+		"negative uint":  {`18446744073709551615`, `-1`},
+		"overflow":       {`18446744073709551615`, `18446744073709551616`},
+		"primary type":   {`"primaryType": "Order"`, `"primaryType": "Invoice"`},
+		"recursive type": {`{"name": "wallet", "type": "address"}`, `{"name": "wallet", "type": "address"}, {"name": "friend", "type": "Person"}`},
+		"no domain":      {`"name": "Libonomy Shop"`, `"name": ""`},
+	} {
+		_, err := Parse([]byte(strings.Replace(orderJSON, replace[0], replace[1], 1)))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	td, err := Parse([]byte(orderJSON))
+	assert.NoError(t, err)
+
+	for _, scheme := range accounts.SchemeNames() {
+		s := accounts.Store{}
+		acc, err := s.CreateAccount("alice", scheme, 0, []byte("beagles"), crypto.DefaultCypherParams)
+		assert.NoError(t, err)
+
+		signed, err := Sign(td, acc, sign)
+		assert.NoError(t, err)
+		assert.NoError(t, signed.Verify(shopDomain))
+		assert.Error(t, signed.Verify(Domain{Name: "Libonomy Shop", Version: "2", NetworkID: 1}))
+
+		// servers receive the signed data as JSON
+		data, err := json.Marshal(signed)
+		assert.NoError(t, err)
+		received := &Signed{}
+		assert.NoError(t, json.Unmarshal(data, received))
+		assert.NoError(t, received.Verify(shopDomain))
+
+		received.Data.Message["item"] = "tea"
+		assert.Error(t, received.Verify(shopDomain))
+	}
+}
+
+func TestFormat(t *testing.T) {
+	td, err := Parse([]byte(orderJSON))
+	assert.NoError(t, err)
+	out := td.Format()
+	for _, s := range []string{`Domain: "Libonomy Shop" (version "1", network 1)`, `item: "coffee"`,
+		"quantity: 18446744073709551615", "buyer (Person):", "wallet: 0x00000000000000000000000000000000000000aa",
+		"tags (string[], 2 items):", `[1]: "large"`} {
+		assert.Contains(t, out, s)
+	}
+}